@@ -0,0 +1,30 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// adaCmd represents the ada command
+var adaCmd = &cobra.Command{
+	Use:   "ada [--peripheral pattern]...",
+	Short: "Generate an ada package spec for the peripherals",
+	Long: `Generate an ada package spec in the style of svd2ada, a record for each register with fields
+	with Volatile_Full_Access and a representation clause eg WLS at 0 range 0 .. 1,
+	an enumeration type for each field with enumerated values, a record for each peripheral type with the
+	registers at their offsets, registers at the same offset are the variants of an Unchecked_Union,
+	and an object for each peripheral at its base address eg UART0_Periph.LCR.WLS := 3.
+	The package is named after the device so save it as eg lpc176x5x.ads.` + select_help,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.GenAda(gen_periphs)
+	},
+}
+
+func init() {
+	add_select_flags(adaCmd)
+	rootCmd.AddCommand(adaCmd)
+}
@@ -0,0 +1,36 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var dump_base string
+
+// annotateCmd represents the annotate command
+var annotateCmd = &cobra.Command{
+	Use:   "annotate dumpfile [--base address]",
+	Short: "Annotate a memory dump with register and field decoding",
+	Long: `Annotate a memory dump with register and field decoding
+	The dump file can be the output of the OpenOCD mdw command, eg
+		0x40013000: 0000034c 00000000 00000007 ...
+	or the GDB x/NNwx command, eg
+		0x40013000:	0x0000034c	0x00000000	0x00000007 ...
+	or if --base is specified it is a raw little endian binary file starting at that address
+	Every word is labelled with its register and decoded fields and grouped by peripheral,
+	words that are not in a known register are marked
+	If -v is specified then descriptions for the registers and fields are also displayed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.Annotate(args[0], dump_base)
+	},
+}
+
+func init() {
+	annotateCmd.Flags().StringVar(&dump_base, "base", "", "Base address of a raw binary dump file")
+
+	rootCmd.AddCommand(annotateCmd)
+}
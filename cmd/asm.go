@@ -10,7 +10,7 @@ import (
 
 // asmCmd represents the asm command
 var asmCmd = &cobra.Command{
-	Use:   "asm --peripheral name [--register regpattern]",
+	Use:   "asm {--peripheral name | --group name} [--register regpattern]... [--exclude pattern]... [--field pattern]...",
 	Short: "Generate asm .equ directives defining registers and fields",
 	Long:  `Generate asm .equ directives defining registers and fields
 		specifying the -r 'pat' will only print out the registers with 'pat' in the name
@@ -19,16 +19,27 @@ var asmCmd = &cobra.Command{
 		that end in a number and output them first
 		eg SPI_n will get SPI0 SPI1 SPI2 etc or TIM_ will get TIM1 TIM2 ... TIM12 etc
 		in this case the register and fields will be generic to any of the registers printed out
-		`,
+		--group name does the same for all the peripherals in the group from the SVD groupName,
+		see list --groups, any that do not have the same registers are noted` + filter_help,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return svd_lookup.GenAsm(periph, reg_pat)
+		filt, err := filter()
+		if err != nil {
+			return err
+		}
+		if group != "" {
+			return svd_lookup.GenAsmGroup(group, filt)
+		}
+		return svd_lookup.GenAsm(periph, filt)
 	},
 }
 
 func init() {
 	asmCmd.Flags().StringVarP(&periph, "peripheral", "p", "", "Peripheral to use")
-	asmCmd.Flags().StringVarP(&reg_pat, "register", "r", "", "Register pattern to filter on")
-	if err := asmCmd.MarkFlagRequired("peripheral"); err != nil { panic(err) }
+	add_filter_flags(asmCmd)
+	asmCmd.Flags().StringVar(&group, "group", "", "Peripheral group to use instead of a peripheral")
+	asmCmd.MarkFlagsOneRequired("peripheral", "group")
+	asmCmd.MarkFlagsMutuallyExclusive("peripheral", "group")
 
+	add_name_completion(asmCmd)
 	rootCmd.AddCommand(asmCmd)
 }
@@ -0,0 +1,32 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// browseCmd represents the browse command
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "Full screen browser of the peripherals, registers and fields",
+	Long: `Full screen terminal browser of the device
+	The left pane is a tree of the peripherals, expand them to see their registers and fields.
+	The right pane shows the details of the selected item, the address, reset value, description,
+	a diagram of the register bits and the enumerated values of fields.
+	Keys:
+	  Enter or → expand, ← collapse
+	  / incremental search of the names, use periph.reg to find a register, n for the next match
+	  f copy the forth constant for the selection to the clipboard, a copy the asm .equ
+	  q quit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.Browse()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+}
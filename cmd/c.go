@@ -0,0 +1,29 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// cCmd represents the c command
+var cCmd = &cobra.Command{
+	Use:   "c [--peripheral pattern]...",
+	Short: "Generate a CMSIS style C header for the peripherals",
+	Long: `Generate a CMSIS style C header with a typedef struct for each peripheral
+	with __IO, __I and __O qualifiers from the register access and RESERVED padding for the gaps,
+	registers at the same offset are put in a union.
+	Then the base address and pointer macros for each peripheral eg SPI1_BASE and SPI1->CR1,
+	and _Pos and _Msk macros for each field eg SPI1_CR1_BR_Pos and SPI1_CR1_BR_Msk` + select_help,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.GenC(gen_periphs)
+	},
+}
+
+func init() {
+	add_select_flags(cCmd)
+	rootCmd.AddCommand(cCmd)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var compare_dbs []string
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare --db old.db --db new.db -p peripheral",
+	Short: "Compare a peripheral on two chips for porting a driver",
+	Long: `Compare the peripheral in two databases eg to port a driver from the RP2040 to the RP2350.
+	The registers are lined up by name, or by offset if the name is not on the new chip (renamed),
+	and shown as moved, new or removed. Each field is classified as identical, moved, resized, new or removed.
+	Finally it says whether the forth words generated for the peripheral on the old chip are still valid on the new one,
+	and if not what changed.`,
+	Args:        cobra.NoArgs,
+	Annotations: formatted,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if in_shell {
+			return fmt.Errorf("the compare command can not be used inside the shell")
+		}
+		return svd_lookup.Compare(compare_dbs, periph)
+	},
+}
+
+func init() {
+	compareCmd.Flags().StringArrayVar(&compare_dbs, "db", nil, "Database to compare, give it twice, the old then the new one")
+	compareCmd.Flags().StringVarP(&periph, "peripheral", "p", "", "Peripheral to compare")
+	if err := compareCmd.MarkFlagRequired("db"); err != nil { panic(err) }
+	if err := compareCmd.MarkFlagRequired("peripheral"); err != nil { panic(err) }
+
+	rootCmd.AddCommand(compareCmd)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// dynamic completion of the --peripheral, --register and --group flags for the shell completion scripts
+// cobra does not run pre_run when completing so the database is opened here
+
+// register the completion functions for whichever of the flags the command has
+func add_name_completion(c *cobra.Command) {
+	if c.Flags().Lookup("peripheral") != nil {
+		if err := c.RegisterFlagCompletionFunc("peripheral", complete_peripheral); err != nil { panic(err) }
+	}
+	if c.Flags().Lookup("register") != nil {
+		if err := c.RegisterFlagCompletionFunc("register", complete_register); err != nil { panic(err) }
+	}
+	if c.Flags().Lookup("group") != nil {
+		if err := c.RegisterFlagCompletionFunc("group", complete_group); err != nil { panic(err) }
+	}
+}
+
+func complete_peripheral(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := open_database(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer svd_lookup.CloseDatabase()
+
+	names, err := svd_lookup.PeripheralNames()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return matching(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func complete_group(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := open_database(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer svd_lookup.CloseDatabase()
+
+	names, err := svd_lookup.GroupNames()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return matching(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// the registers of the peripheral given with -p
+func complete_register(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	p, err := cmd.Flags().GetString("peripheral")
+	if err != nil || p == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	if err := open_database(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer svd_lookup.CloseDatabase()
+
+	names, err := svd_lookup.RegisterNames(p)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return matching(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// the names starting with prefix ignoring case
+func matching(names []string, prefix string) []string {
+	var m []string
+	for _, n := range names {
+		if strings.HasPrefix(strings.ToLower(n), strings.ToLower(prefix)) {
+			m = append(m, n)
+		}
+	}
+	return m
+}
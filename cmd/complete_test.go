@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wolfmanjm/svd_lookup/svd2db"
+)
+
+func TestFlagCompletion(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test3.db")
+	if err := svd2db.Convert("../svd2db/testdata/test3.svd", fn); err != nil {
+		t.Fatalf(`Convert(test3.svd) = %v, want nil`, err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"__complete", "display", "-d", fn, "-p", "tim"}, "TIMER0\nTIMER1\nTIMER2\nTIMER3\n:4\n"},
+		{[]string{"__complete", "decode", "-d", fn, "-p", "timer1", "-r", "c"}, "CCR\nCR[%s]\nCTCR\n:4\n"},
+		{[]string{"__complete", "forth", "-d", fn, "-r", ""}, ":4\n"},
+		{[]string{"__complete", "asm", "-d", fn, "--group", "ua"}, "UART0\nUART1\n:4\n"},
+	}
+
+	for _, tc := range tests {
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs(tc.args)
+		err := rootCmd.Execute()
+		for _, c := range rootCmd.Commands() {
+			reset_flags(c)
+		}
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+
+		if err != nil {
+			t.Errorf(`%v = %v, want nil`, strings.Join(tc.args, " "), err)
+		}
+		if out.String() != tc.want {
+			t.Errorf(`%v = %q, want %q`, strings.Join(tc.args, " "), out.String(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var reg_name string
+
+// decodeCmd represents the decode command
+var decodeCmd = &cobra.Command{
+	Use:   "decode {--peripheral name --register name value | periph.reg=value}",
+	Short: "Decode a register value into its fields",
+	Long: `Decode a register value into its fields
+	Either use -p and -r to specify the register and give the value, eg decode -p SPI1 -r CR1 0x0000034C
+	or specify all of it in one argument, eg decode SPI1.CR1=0x34C
+	Each field is shown with its value, the matching enumerated value name if known and whether
+	it differs from the reset value of the field. Any bits set in reserved areas are flagged.
+	The value may be in hex (0x), binary (0b) or decimal
+	If -v is specified then the field descriptions are also displayed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, r, v, err := split_reg_spec(args[0])
+		if err != nil {
+			return err
+		}
+		return svd_lookup.Decode(p, r, v)
+	},
+}
+
+// splits periph.reg=value, if the periph.reg= part is missing then the -p and -r flags are used
+func split_reg_spec(arg string) (string, string, string, error) {
+	spec, value, found := strings.Cut(arg, "=")
+	if !found {
+		if periph == "" || reg_name == "" {
+			return "", "", "", fmt.Errorf("either specify -p and -r or use the form periph.reg=value")
+		}
+		return periph, reg_name, arg, nil
+	}
+
+	p, r, found := strings.Cut(spec, ".")
+	if !found || p == "" || r == "" {
+		return "", "", "", fmt.Errorf("%v is not of the form periph.reg=value", arg)
+	}
+	return p, r, value, nil
+}
+
+func init() {
+	decodeCmd.Flags().StringVarP(&periph, "peripheral", "p", "", "Peripheral to use")
+	decodeCmd.Flags().StringVarP(&reg_name, "register", "r", "", "Register to decode")
+
+	add_name_completion(decodeCmd)
+	rootCmd.AddCommand(decodeCmd)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var diff_periphs []string
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff old.db new.db [--peripheral name]...",
+	Short: "Compare two databases eg two revisions of a chip",
+	Long: `Compare two databases and report the peripherals, registers, fields and enumerated values
+	that were added (+) or removed (-) and the ones that changed (~) their base address, offset, size,
+	bit position, width, reset value, access or value.
+	Changes to peripherals derived from the same peripheral in both are reported for the one they are derived from.
+	--peripheral limits the comparison to the matching peripherals and can be given more than once,
+	the patterns are the same as for --register`,
+	Args:        cobra.ExactArgs(2),
+	Annotations: formatted,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if in_shell {
+			return fmt.Errorf("the diff command can not be used inside the shell")
+		}
+		return svd_lookup.Diff(args[0], args[1], diff_periphs)
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringArrayVarP(&diff_periphs, "peripheral", "p", nil, "Only compare the peripherals matching this pattern")
+
+	rootCmd.AddCommand(diffCmd)
+}
@@ -8,27 +8,31 @@ import (
 	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
 )
 
-var reg_pat string
-
 // displayCmd represents the display command
 var displayCmd = &cobra.Command{
-	Use:   "display --peripheral name [--register regpattern]",
+	Use:   "display --peripheral name [--register regpattern]... [--exclude pattern]... [--field pattern]...",
 	Short: "Human readable display of the registers and fields for the specified peripheral",
 	Long: `Human readable display of the registers and fields for the specified peripheral
 	If -v is specified then descriptions for the registers and fields is also displayed
 	If -r is specified then only the registers that match that pattern will be displayed
-	The -p name may contain % as a wildcard for matching the peripheral name`,
+	The -p name may contain % as a wildcard for matching the peripheral name` + filter_help,
 	Args: cobra.NoArgs,
 	Aliases: []string{"d", "disp"},
+	Annotations: formatted,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return svd_lookup.Display(periph, reg_pat)
+		filt, err := filter()
+		if err != nil {
+			return err
+		}
+		return svd_lookup.Display(periph, filt)
 	},
 }
 
 func init() {
 	displayCmd.Flags().StringVarP(&periph, "peripheral", "p", "", "Peripheral to use")
-	displayCmd.Flags().StringVarP(&reg_pat, "register", "r", "", "Register pattern to filter on")
+	add_filter_flags(displayCmd)
 
 	if err := displayCmd.MarkFlagRequired("peripheral"); err != nil { panic(err) }
+	add_name_completion(displayCmd)
 	rootCmd.AddCommand(displayCmd)
 }
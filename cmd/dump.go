@@ -13,6 +13,7 @@ var dumpCmd = &cobra.Command{
 	Use:   "dump",
 	Short: "Dumps the SVD database",
 	Long: `Dump out the entire database`,
+	Annotations: formatted,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return svd_lookup.Dump()
 	},
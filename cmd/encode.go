@@ -0,0 +1,47 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// encodeCmd represents the encode command
+var encodeCmd = &cobra.Command{
+	Use:   "encode {--peripheral name --register name | periph.reg} field=value...",
+	Short: "Encode field assignments into a register value and mask",
+	Long: `Encode field assignments into a register value and write mask
+	Either use -p and -r to specify the register, eg encode -p SPI1 -r CR1 SPE=1 BR=0b011
+	or specify it as the first argument, eg encode SPI1.CR1 SPE=1 BR=0b011 MSTR=1
+	The value may be in hex (0x), binary (0b) or decimal, or the name of one of the fields enumerated values
+	Values that are too wide for the field are rejected.
+	As well as the value and mask it prints forth, asm and C code to write the fields`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if periph != "" && reg_name != "" {
+			return svd_lookup.Encode(periph, reg_name, args)
+		}
+
+		p, r, found := strings.Cut(args[0], ".")
+		if !found || p == "" || r == "" || strings.Contains(r, "=") {
+			return fmt.Errorf("either specify -p and -r or start with periph.reg")
+		}
+		if len(args) < 2 {
+			return fmt.Errorf("no field assignments given")
+		}
+		return svd_lookup.Encode(p, r, args[1:])
+	},
+}
+
+func init() {
+	encodeCmd.Flags().StringVarP(&periph, "peripheral", "p", "", "Peripheral to use")
+	encodeCmd.Flags().StringVarP(&reg_name, "register", "r", "", "Register to encode")
+
+	add_name_completion(encodeCmd)
+	rootCmd.AddCommand(encodeCmd)
+}
@@ -0,0 +1,32 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// the register and field filter flags shared by display and the generators
+var reg_pats []string
+var exclude_pats []string
+var field_pats []string
+
+const filter_help = `
+	The -r, --exclude and --field patterns may be repeated and are either
+	  text      matches names containing the text, eg -r CR matches CR1 and CCR
+	  glob      if it has any of * ? [ it must match the whole name, eg -r 'CR?' or --exclude '*_ISR'
+	  re:regex  a regular expression, eg -r 're:^(CR|SR)[0-9]*$'
+	all ignoring case. Registers matching any -r and no --exclude are selected,
+	with --field only the matching fields are shown and registers without any are left out`
+
+func add_filter_flags(c *cobra.Command) {
+	c.Flags().StringArrayVarP(&reg_pats, "register", "r", nil, "Register pattern to filter on, may be repeated")
+	c.Flags().StringArrayVar(&exclude_pats, "exclude", nil, "Register pattern to leave out, may be repeated")
+	c.Flags().StringArrayVar(&field_pats, "field", nil, "Field pattern to filter on, may be repeated")
+}
+
+func filter() (svd_lookup.Filter, error) {
+	return svd_lookup.NewFilter(reg_pats, exclude_pats, field_pats)
+}
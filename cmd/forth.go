@@ -11,10 +11,18 @@ import (
 var forth_type bool
 // forthCmd represents the forth command
 var forthCmd = &cobra.Command{
-	Use:   "forth --peripheral name [--register regpattern]",
+	Use:   "forth {--peripheral name | --group name} [--register regpattern]... [--exclude pattern]... [--field pattern]...",
 	Short: "Generate forth words to access the specified peripheral",
 	Long: `Generates forth words to access the specified peripheral
-	By default it generates constants, by using the --freg flag it will instead generate words that use the register format`,
+	By default it generates constants, by using the --freg flag it will instead generate words that use the register format
+	--group name generates the base of every peripheral in the group from the SVD groupName, see list --groups,
+	and one set of register and field words they all use. The constants are then offsets from the base,
+	eg GPIOA_BASE gpio_ODR +, and with --freg the registers words are used with any of the instances eg GPIOB _gpODR
+	If the peripheral name ends in '_n' all the numbered instances are generated the same way as a group,
+	eg -p TIMER_n gives TIMER0_BASE to TIMER3_BASE and timer_IR etc, or with --freg TIMER0 to TIMER3 and _tiIR etc
+	--fieldwords also generates words to read and write each field with modify-reg eg SPI1_CR1_BR@ ( -- n ) and
+	SPI1_CR1_BR! ( n -- ), and a constant for each enumerated value eg SPI1_CR1_BR_DIV8, for a group or _n they take
+	the base of the instance eg TIMER1_BASE TIMER_MCR_MR0I@, --addwords then adds read-reg as well as modify-reg` + filter_help,
 	Aliases: []string{"fth"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		b, err := cmd.Flags().GetBool("addwords")
@@ -22,20 +30,39 @@ var forthCmd = &cobra.Command{
 			b = false
 		}
 		svd_lookup.Addwords = b
+		svd_lookup.Fieldwords, err = cmd.Flags().GetBool("fieldwords")
+		if err != nil {
+			return err
+		}
+		filt, err := filter()
+		if err != nil {
+			return err
+		}
+		if group != "" {
+			if forth_type {
+				return svd_lookup.GenForthRegsGroup(group, filt)
+			}
+			return svd_lookup.GenForthConstsGroup(group, filt)
+		}
 		if forth_type {
-			return svd_lookup.GenForthRegs(periph, reg_pat)
+			return svd_lookup.GenForthRegs(periph, filt)
 		} else {
-			return svd_lookup.GenForthConsts(periph, reg_pat)
+			return svd_lookup.GenForthConsts(periph, filt)
 		}
 	},
 }
 
 func init() {
 	forthCmd.Flags().StringVarP(&periph, "peripheral", "p", "", "Peripheral to use")
-	forthCmd.Flags().StringVarP(&reg_pat, "register", "r", "", "Register pattern to filter on")
+	add_filter_flags(forthCmd)
 	forthCmd.Flags().BoolVar(&forth_type, "freg", false, "Generate register format")
 	forthCmd.Flags().Bool("addwords", false, "Add the support words")
+	forthCmd.Flags().Bool("fieldwords", false, "Add field accessor words and enumerated value constants")
+	forthCmd.MarkFlagsMutuallyExclusive("freg", "fieldwords")
 
-	if err := forthCmd.MarkFlagRequired("peripheral"); err != nil { panic(err) }
+	forthCmd.Flags().StringVar(&group, "group", "", "Peripheral group to use instead of a peripheral")
+	forthCmd.MarkFlagsOneRequired("peripheral", "group")
+	forthCmd.MarkFlagsMutuallyExclusive("peripheral", "group")
+	add_name_completion(forthCmd)
 	rootCmd.AddCommand(forthCmd)
 }
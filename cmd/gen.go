@@ -0,0 +1,62 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var gen_template string
+var show_template string
+var gen_plugin string
+var plugin_opt string
+var plugin_out string
+
+// genCmd represents the gen command
+var genCmd = &cobra.Command{
+	Use:   "gen {--template file | --show-template name | --plugin name [--opt parameter] [--out dir]} [--peripheral pattern]...",
+	Short: "Generate output from a go text/template or an external plugin",
+	Long: `Renders a go text/template file against the device model, the same model the json output writes,
+	with the registers of each peripheral sorted by offset, eg {{range .Peripherals}}{{.Name}} {{hex .BaseAddress}}{{end}}.
+	--template is a template file or the name of a bundled template, the bundled templates are ` + strings.Join(svd_lookup.BundledTemplates(), ", ") + `
+	which give the same definitions as the asm, forth and forth --freg output, but asm and forth list the registers in offset
+	order where the commands list them by name. --show-template name prints a bundled template to copy and change.
+	The functions are upper, lower, title, camel, snake, ident, clean and truncate n for names and descriptions,
+	hex and hexw digits for numbers, mask width [offset], field_value field value, int, add and sub,
+	forth_hex, forth_hexw digits, forth_comment and forth_string for forth, asm_name, asm_comment and asm_string for asm.
+	--plugin name runs an external generator found on the PATH, eg svd-gen-foo, like a protoc plugin.
+	It is sent {"version": 1, "generator": "svd_lookup", "parameter": "--opt value", "device": {the model}} as json on stdin
+	and writes {"files": [{"name": "relative/path", "content": "..."}]} to stdout, or {"error": "message"},
+	the files are written under --out (default the current directory) and their paths printed.` + select_help,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if show_template != "" {
+			return svd_lookup.ShowTemplate(show_template)
+		}
+		if gen_plugin != "" {
+			return svd_lookup.GenPlugin(gen_periphs, gen_plugin, plugin_opt, plugin_out)
+		}
+		return svd_lookup.GenTemplate(gen_periphs, gen_template)
+	},
+}
+
+func init() {
+	add_select_flags(genCmd)
+	genCmd.Flags().StringVarP(&gen_template, "template", "t", "", "Template file or bundled template to render")
+	genCmd.Flags().StringVar(&show_template, "show-template", "", "Print the bundled template")
+	genCmd.Flags().StringVar(&gen_plugin, "plugin", "", "External generator to run, found on the PATH")
+	genCmd.Flags().StringVar(&plugin_opt, "opt", "", "Parameter passed to the plugin")
+	genCmd.Flags().StringVar(&plugin_out, "out", ".", "Directory the plugin files are written to")
+	genCmd.MarkFlagsOneRequired("template", "show-template", "plugin")
+	genCmd.MarkFlagsMutuallyExclusive("template", "show-template", "plugin")
+	if err := genCmd.RegisterFlagCompletionFunc("show-template", complete_bundled_template); err != nil { panic(err) }
+	rootCmd.AddCommand(genCmd)
+}
+
+func complete_bundled_template(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return svd_lookup.BundledTemplates(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -8,18 +8,34 @@ import (
 	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
 )
 
+var list_groups bool
+var group string
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all peripherals",
-	Long: `List the Peripheral names available`,
+	Long: `List the Peripheral names available
+	--groups lists the peripheral groups from the SVD groupName and the peripherals in them,
+	--group name lists just the peripherals in that group`,
 	Aliases: []string{"l", "lst"},
+	Annotations: formatted,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if list_groups {
+			return svd_lookup.ListGroups()
+		}
+		if group != "" {
+			return svd_lookup.ListGroup(group)
+		}
 		return svd_lookup.List()
 	},
 }
 
 func init() {
-	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().BoolVar(&list_groups, "groups", false, "List the peripheral groups")
+	listCmd.Flags().StringVar(&group, "group", "", "List the peripherals in the group")
+	listCmd.MarkFlagsMutuallyExclusive("groups", "group")
+	add_name_completion(listCmd)
 
+	rootCmd.AddCommand(listCmd)
 }
@@ -0,0 +1,35 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var includes []string
+
+// lspCmd represents the lsp command
+var lspCmd = &cobra.Command{
+	Use:   "lsp [--include file]...",
+	Short: "Language server for the generated register names",
+	Long: `Language server speaking LSP over stdin/stdout for forth, asm and C sources
+	that use the names generated by the forth and asm commands, eg m_spi1_CR2_TSER, _spCR1 or b_CR1_SSI
+	Hover shows the register or field description, bit range and reset value,
+	completion offers all the valid generated names, and go to definition finds the name
+	in the generated include files given with --include (forth constants, asm .equ or C #define)
+	Configure the editor to run eg svd_lookup -d mympu.db lsp --include regs.fs`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.LSP(os.Stdin, os.Stdout, includes)
+	},
+}
+
+func init() {
+	lspCmd.Flags().StringArrayVar(&includes, "include", nil, "generated include file to find definitions in, may be repeated")
+
+	rootCmd.AddCommand(lspCmd)
+}
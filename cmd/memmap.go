@@ -0,0 +1,31 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// memmapCmd represents the memmap command
+var memmapCmd = &cobra.Command{
+	Use:   "memmap",
+	Short: "Memory map of all peripherals sorted by base address",
+	Long: `Memory map of all peripherals sorted by base address
+	Shows the base address, size and end address of the address block of every peripheral.
+	Unused gaps between the blocks are shown and blocks that overlap are flagged,
+	which is either real aliasing or an error in the SVD.
+	Databases converted before address blocks were stored do not have the sizes so they are estimated
+	from the last register, these are marked with ~
+	With --format it can be output as csv, json or yaml, eg for checking against linker scripts`,
+	Args: cobra.NoArgs,
+	Annotations: formatted,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.Memmap()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(memmapCmd)
+}
@@ -0,0 +1,29 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// micropythonCmd represents the micropython command
+var micropythonCmd = &cobra.Command{
+	Use:   "micropython [--peripheral pattern]...",
+	Short: "Generate a micropython module with uctypes layouts for the peripherals",
+	Long: `Generate a micropython module with a uctypes layout for each peripheral type,
+	the fields are BFUINT32 | pos << BF_POS | len << BF_LEN bitfields so can be set by name eg UART0.LCR.WLS = 3.
+	There are also const addresses for each peripheral and register and _Pos and _Msk constants for each field
+	for use with machine.mem32 eg machine.mem32[UART0_LCR] |= UART0_LCR_WLS_Msk.
+	The whole device is usually too big for the RAM of a board so select the peripherals that are needed.` + select_help,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.GenMicroPython(gen_periphs)
+	},
+}
+
+func init() {
+	add_select_flags(micropythonCmd)
+	rootCmd.AddCommand(micropythonCmd)
+}
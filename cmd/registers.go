@@ -14,6 +14,7 @@ var registersCmd = &cobra.Command{
 	Short: "List all the registers for the specified peripheral",
 	Long: `Just a list of register names`,
 	Aliases: []string{"r", "regs"},
+	Annotations: formatted,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return svd_lookup.Registers(periph)
 	},
@@ -22,5 +23,6 @@ var registersCmd = &cobra.Command{
 func init() {
 	registersCmd.Flags().StringVarP(&periph, "peripheral", "p", "", "Peripheral to use")
 	if err := registersCmd.MarkFlagRequired("peripheral"); err != nil { panic(err) }
+	add_name_completion(registersCmd)
 	rootCmd.AddCommand(registersCmd)
 }
@@ -4,7 +4,9 @@ Copyright © 2025 Jim Morris <morris@wolfman.com>
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"github.com/spf13/cobra"
 	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
 )
@@ -13,6 +15,10 @@ var verbose bool
 var cwd string
 var database string
 var periph string
+var format string
+
+// commands with this annotation support the --format flag
+var formatted = map[string]string{"format": "true"}
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -36,26 +42,63 @@ func Execute() {
 	}
 }
 
+// convert and the shell completion commands do not use the database, diff and compare open the ones they are given
+func needs_database(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "convert", "diff", "compare", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return false
+	case "gen":
+		// the bundled templates are shown without a database
+		return !cmd.Flags().Changed("show-template")
+	}
+	return !(cmd.HasParent() && cmd.Parent().Name() == "completion") && cmd.Name() != "completion"
+}
+
 func pre_run(cmd *cobra.Command, args []string) error {
-	if cmd.Name() != "convert" {
-		if cwd != "" {
-			svd_lookup.SetSearchPath(cwd)
+	if needs_database(cmd) {
+		// inside the shell the database is already open
+		if in_shell {
+			if cmd.Flags().Changed("curdir") || cmd.Flags().Changed("database") {
+				return fmt.Errorf("the -c and -d flags can not be used inside the shell")
+			}
+			return set_options(cmd)
 		}
-		if database != "" {
-			svd_lookup.SetDatabase(database)
-		}
-		if verbose {
-			svd_lookup.SetVerbose()
+
+		if err := set_options(cmd); err != nil {
+			return err
 		}
-		return svd_lookup.OpenDatabase()
+		return open_database()
+
+	} else if cmd.Annotations["format"] != "" {
+		return set_options(cmd)
 
 	} else {
 		return nil
 	}
 }
 
+// open the database given by --database or found from --curdir
+func open_database() error {
+	if cwd != "" {
+		svd_lookup.SetSearchPath(cwd)
+	}
+	if database != "" {
+		svd_lookup.SetDatabase(database)
+	}
+	return svd_lookup.OpenDatabase()
+}
+
+// set the global options that are reset for each command run in the shell
+func set_options(cmd *cobra.Command) error {
+	svd_lookup.SetVerbose(verbose)
+	if format != "text" && cmd.Annotations["format"] == "" {
+		return fmt.Errorf("the %v command does not support --format", cmd.Name())
+	}
+	return svd_lookup.SetFormat(format)
+}
+
 func post_run(cmd *cobra.Command, args []string) {
-	if cmd.Name() != "convert" {
+	if needs_database(cmd) && !in_shell {
 		svd_lookup.CloseDatabase()
 	}
 }
@@ -64,6 +107,7 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVarP(&cwd, "curdir", "c", "", "set the current directory for db search")
 	rootCmd.PersistentFlags().StringVarP(&database, "database", "d", "", "use the named database")
+	rootCmd.PersistentFlags().StringVar(&format, "format", "text", "output format for the query commands, one of " + strings.Join(svd_lookup.Formats, ", "))
 	rootCmd.MarkFlagsMutuallyExclusive("curdir", "database")
 }
 
@@ -0,0 +1,31 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// rustCmd represents the rust command
+var rustCmd = &cobra.Command{
+	Use:   "rust [--peripheral pattern]...",
+	Short: "Generate a no_std rust module for the peripherals",
+	Long: `Generate a no_std rust module with no dependencies, a module for each peripheral
+	with the BASE address, the register offsets eg CR1_OFFSET and a register constant eg CR1
+	typed with its width and access, then a module for each register with a constant for each field.
+	Registers have read, write and modify using read_volatile and write_volatile,
+	and read_field and write_field eg spi1::CR1.write_field(spi1::cr1::BR, 3), read-only registers
+	have no write and write-only ones no read.
+	The output is rustfmt clean so can be checked in eg svd_lookup rust > src/rp2040.rs` + select_help,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.GenRust(gen_periphs)
+	},
+}
+
+func init() {
+	add_select_flags(rustCmd)
+	rootCmd.AddCommand(rustCmd)
+}
@@ -0,0 +1,22 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// the peripherals selected for the generators that output the whole device, eg c and rust
+
+var gen_periphs []string
+
+const select_help = `
+	By default all the peripherals are generated, -p selects the peripherals matching the pattern
+	and may be repeated, the patterns are the same as for --register eg -p 'SPI*' -p USART1.
+	Derived peripherals use the type of the one they are derived from.`
+
+func add_select_flags(c *cobra.Command) {
+	c.Flags().StringArrayVarP(&gen_periphs, "peripheral", "p", nil, "Peripheral pattern to generate, may be repeated, default all")
+	add_name_completion(c)
+}
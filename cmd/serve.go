@@ -0,0 +1,37 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var listen string
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a JSON API and web browser of the database over http",
+	Long: `Serve a JSON API and web browser of the database over http
+	so the registers can be looked up without installing svd_lookup, eg serve --listen 0.0.0.0:8080
+	Open http://127.0.0.1:8080/ in a browser, or use the API:
+	  /mpus                       the MPUs in the database
+	  /peripherals                all the peripherals without their registers
+	  /peripherals/{name}         the peripheral with its registers and fields
+	  /search?q=text              peripherals, registers and fields with text in their name
+	  /decode?reg=SPI1.CR1&value=0x34C   decode a register value, or use peripheral=SPI1&register=CR1
+	  /addr?a=0x40013004          the peripheral and register at an address
+	All the API results are json in the same structure as --format json, errors are {"error": "..."}`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.Serve(listen)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&listen, "listen", "127.0.0.1:8080", "address and port to listen on")
+
+	rootCmd.AddCommand(serveCmd)
+}
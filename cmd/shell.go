@@ -0,0 +1,457 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// set while commands are being run from inside the shell, so the database is not reopened
+var in_shell bool
+// the values of the root flags when the shell was started, the commands run in the shell keep these
+var shell_root_flags map[string]string
+
+// where the names for completion come from, the tests replace these
+var peripheral_names = svd_lookup.PeripheralNames
+var register_names = svd_lookup.RegisterNames
+var field_names = svd_lookup.FieldNames
+
+// the commands built into the shell, anything else is run as a subcommand
+var shell_builtins = []string{"cd", "ls", "pwd", "show", "help", "exit", "quit"}
+
+const shell_help = `Shell commands
+	cd [periph[/reg] | .. | /]  change the current peripheral and register
+	ls                          list the peripherals, registers of the current peripheral or fields of the current register
+	pwd                         show the current peripheral and register
+	show [reg]                  display the register, or the current one
+	help                        this help
+	exit or quit                leave the shell (or use ctrl-D)
+Any other svd_lookup command can be run, if it has -p or -r flags and they are not given then
+the current peripheral and register are used. eg in SPI1/CR1 decode 0x34C
+Tab completes commands, peripheral, register and field names`
+
+// shellCmd represents the shell command
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive shell with tab completion",
+	Long: `Interactive shell that keeps the database open
+	cd into a peripheral and register, then ls, show and decode work on them without having to give -p and -r
+	The other commands can be run in the shell as well.
+	Tab completes the commands and the peripheral, register and field names from the database
+	The command history is kept in ~/.svd_lookup_history`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if in_shell {
+			return fmt.Errorf("already in the shell")
+		}
+		in_shell = true
+		defer func() { in_shell = false }()
+		shell_root_flags = make(map[string]string)
+		rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+			shell_root_flags[f.Name] = f.Value.String()
+		})
+
+		line := liner.NewLiner()
+		defer line.Close()
+		line.SetCtrlCAborts(true)
+
+		sh := &shell_state{}
+		line.SetWordCompleter(sh.complete)
+
+		history := history_file()
+		if f, err := os.Open(history); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+
+		fmt.Println("svd_lookup shell, type help for help")
+		for {
+			input, err := line.Prompt(sh.prompt())
+			if err == liner.ErrPromptAborted {
+				continue
+			}
+			if err == io.EOF {
+				fmt.Println()
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			words := strings.Fields(input)
+			if len(words) == 0 {
+				continue
+			}
+			line.AppendHistory(input)
+
+			if words[0] == "exit" || words[0] == "quit" {
+				break
+			}
+			if err := sh.execute(words); err != nil {
+				fmt.Println("Error:", err)
+			}
+		}
+
+		if f, err := os.Create(history); err == nil {
+			line.WriteHistory(f)
+			f.Close()
+		}
+
+		return nil
+	},
+}
+
+func history_file() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".svd_lookup_history"
+	}
+	return filepath.Join(home, ".svd_lookup_history")
+}
+
+// where we are in the shell
+type shell_state struct {
+	periph string
+	reg string
+}
+
+func (sh *shell_state) prompt() string {
+	switch {
+	case sh.reg != "":
+		return sh.periph + "/" + sh.reg + "> "
+	case sh.periph != "":
+		return sh.periph + "> "
+	default:
+		return "svd> "
+	}
+}
+
+func (sh *shell_state) execute(words []string) error {
+	switch words[0] {
+	case "help":
+		fmt.Println(shell_help)
+		return run_command([]string{"help"})
+
+	case "pwd":
+		fmt.Println("/" + strings.TrimSuffix(sh.periph + "/" + sh.reg, "/"))
+		return nil
+
+	case "cd":
+		if len(words) == 1 {
+			sh.periph, sh.reg = "", ""
+			return nil
+		}
+		return sh.cd(words[1])
+
+	case "ls":
+		var names []string
+		var err error
+		switch {
+		case sh.reg != "":
+			names, err = field_names(sh.periph, sh.reg)
+		case sh.periph != "":
+			names, err = register_names(sh.periph)
+		default:
+			names, err = peripheral_names()
+		}
+		if err != nil {
+			return err
+		}
+		for _, n := range names {
+			fmt.Println(n)
+		}
+		return nil
+
+	case "show":
+		reg := sh.reg
+		if len(words) > 1 {
+			reg = words[1]
+		}
+		if sh.periph == "" {
+			if reg == "" {
+				return fmt.Errorf("show needs a peripheral, cd into one first")
+			}
+			// at the top level show the named peripheral
+			return run_command([]string{"display", "-p", reg})
+		}
+		if reg == "" {
+			return run_command([]string{"display", "-p", sh.periph})
+		}
+		return svd_lookup.Show(sh.periph, reg)
+
+	default:
+		return run_command(sh.add_context(words))
+	}
+}
+
+func (sh *shell_state) cd(path string) error {
+	switch path {
+	case "/":
+		sh.periph, sh.reg = "", ""
+		return nil
+	case "..":
+		if sh.reg != "" {
+			sh.reg = ""
+		} else {
+			sh.periph = ""
+		}
+		return nil
+	}
+
+	periph, reg := sh.periph, ""
+	p, r, found := strings.Cut(strings.Replace(path, ".", "/", 1), "/")
+	if found {
+		periph, reg = p, r
+	} else if sh.periph == "" {
+		periph = p
+	} else {
+		reg = p
+	}
+
+	pn, err := svd_lookup.PeripheralName(periph)
+	if err != nil {
+		return err
+	}
+	if reg != "" {
+		reg, err = svd_lookup.RegisterName(pn, reg)
+		if err != nil {
+			return err
+		}
+	}
+
+	sh.periph, sh.reg = pn, reg
+	return nil
+}
+
+// add -p and -r for the current peripheral and register if the command has them and they were not given
+func (sh *shell_state) add_context(words []string) []string {
+	c, _, err := rootCmd.Find(words)
+	if err != nil || c == rootCmd {
+		return words
+	}
+
+	// a periph.reg argument overrides the context
+	for _, w := range words[1:] {
+		if spec, _, _ := strings.Cut(w, "="); strings.Contains(spec, ".") && !strings.HasPrefix(w, "-") {
+			return words
+		}
+	}
+
+	if sh.periph == "" || c.Flags().Lookup("peripheral") == nil || has_flag(c, words, "-p", "--peripheral") {
+		return words
+	}
+	words = append(words, "-p", sh.periph)
+
+	// the register is only added with the peripheral it belongs to, not to a peripheral given with -p
+	if sh.reg != "" && c.Flags().Lookup("register") != nil && !has_flag(c, words, "-r", "--register") {
+		words = append(words, "-r", sh.reg)
+	}
+
+	return words
+}
+
+// whether the flag is given, the values of the other flags are skipped so eg --field -rx is not -r
+// and short flags can be clustered with the value attached eg -vpSPI1
+func has_flag(c *cobra.Command, words []string, short string, long string) bool {
+	lookup := func(name string) *pflag.Flag {
+		if f := c.Flags().Lookup(name); f != nil {
+			return f
+		}
+		return c.InheritedFlags().Lookup(name)
+	}
+	lookup_short := func(name string) *pflag.Flag {
+		if f := c.Flags().ShorthandLookup(name); f != nil {
+			return f
+		}
+		return c.InheritedFlags().ShorthandLookup(name)
+	}
+
+	for i := 1; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case w == long || strings.HasPrefix(w, long + "="):
+			return true
+
+		case strings.HasPrefix(w, "--"):
+			// a flag that takes a value without = takes the next word
+			name, _, has_value := strings.Cut(w[2:], "=")
+			if f := lookup(name); f != nil && f.NoOptDefVal == "" && !has_value {
+				i++
+			}
+
+		case strings.HasPrefix(w, "-") && len(w) > 1:
+			// the first short flag in the cluster that takes a value has the rest of the word, or the next word
+			for j := 1; j < len(w); j++ {
+				if "-" + w[j:j+1] == short {
+					return true
+				}
+				f := lookup_short(w[j:j+1])
+				if f == nil || f.NoOptDefVal != "" {
+					continue
+				}
+				if j == len(w) - 1 {
+					i++
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+// run one of the svd_lookup commands from inside the shell
+func run_command(words []string) error {
+	c, _, err := rootCmd.Find(words)
+	if err != nil {
+		return err
+	}
+	if c.Name() == "shell" {
+		return fmt.Errorf("already in the shell")
+	}
+
+	// flags keep their values from the previous command so they need to be reset
+	reset_flags(c)
+	rootCmd.SetArgs(words)
+
+	// cobra prints any error itself
+	rootCmd.Execute()
+	return nil
+}
+
+// the flags of the command keep their values from the last time it was run so are reset to their defaults,
+// the root flags are put back to what they were when the shell was started eg -v
+func reset_flags(c *cobra.Command) {
+	c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace([]string{})
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		v, ok := shell_root_flags[f.Name]
+		if !ok {
+			v = f.DefValue
+		}
+		f.Value.Set(v)
+		f.Changed = false
+	})
+}
+
+// liner word completer, completes the word the cursor is on
+func (sh *shell_state) complete(line string, pos int) (string, []string, string) {
+	head, tail := line[:pos], line[pos:]
+	start := strings.LastIndex(head, " ") + 1
+	word := head[start:]
+
+	var completions []string
+	for _, c := range sh.candidates(strings.Fields(head[:start]), word) {
+		if strings.HasPrefix(strings.ToLower(c), strings.ToLower(word)) {
+			completions = append(completions, c)
+		}
+	}
+
+	return head[:start], completions, tail
+}
+
+// all the possible completions of word given the words before it
+func (sh *shell_state) candidates(words []string, word string) []string {
+	if len(words) == 0 {
+		names := slices.Clone(shell_builtins)
+		for _, c := range rootCmd.Commands() {
+			if !c.Hidden {
+				names = append(names, c.Name())
+			}
+		}
+		return names
+	}
+
+	// the peripheral given with -p, or the current one
+	periph := sh.periph
+	for i, w := range words[:len(words)-1] {
+		if w == "-p" || w == "--peripheral" {
+			periph = words[i+1]
+		}
+	}
+
+	switch words[len(words)-1] {
+	case "-p", "--peripheral":
+		return ignore_error(peripheral_names())
+	case "-r", "--register":
+		if periph == "" {
+			return nil
+		}
+		return ignore_error(register_names(periph))
+	}
+
+	// periph.reg or periph/reg completes the register names of periph
+	if i := strings.IndexAny(word, "./"); i > 0 && !strings.Contains(word, "=") {
+		var names []string
+		for _, r := range ignore_error(register_names(word[:i])) {
+			names = append(names, word[:i+1] + r)
+		}
+		return names
+	}
+
+	switch words[0] {
+	case "cd", "show":
+		if len(words) > 1 {
+			return nil
+		}
+		if periph == "" {
+			return ignore_error(peripheral_names())
+		}
+		if sh.reg == "" || words[0] == "show" {
+			return ignore_error(register_names(periph))
+		}
+		return nil
+
+	case "encode":
+		// field=value assignments for the register given as periph.reg or the current one
+		reg := sh.reg
+		if len(words) > 1 {
+			if p, r, found := strings.Cut(words[1], "."); found {
+				periph, reg = p, r
+			}
+		}
+		if periph == "" || reg == "" {
+			return nil
+		}
+		var names []string
+		for _, f := range ignore_error(field_names(periph, reg)) {
+			names = append(names, f + "=")
+		}
+		return names
+
+	case "decode":
+		if sh.periph != "" && sh.reg == "" {
+			return ignore_error(register_names(sh.periph))
+		}
+		return ignore_error(peripheral_names())
+	}
+
+	return nil
+}
+
+func ignore_error(names []string, err error) []string {
+	if err != nil {
+		return nil
+	}
+	return names
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
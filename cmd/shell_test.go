@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func stub_names() {
+	peripheral_names = func() ([]string, error) { return []string{"SPI1", "SPI2", "UART0"}, nil }
+	register_names = func(p string) ([]string, error) { return []string{"CR1", "CR2", "SR"}, nil }
+	field_names = func(p string, r string) ([]string, error) { return []string{"SPE", "BR"}, nil }
+}
+
+func TestShellComplete(t *testing.T) {
+	stub_names()
+
+	tests := []struct {
+		sh shell_state
+		line string
+		head string
+		want []string
+	}{
+		{shell_state{}, "sh", "", []string{"show", "shell"}},
+		{shell_state{}, "cd SP", "cd ", []string{"SPI1", "SPI2"}},
+		{shell_state{periph: "SPI1"}, "cd c", "cd ", []string{"CR1", "CR2"}},
+		{shell_state{periph: "SPI1"}, "display -p ua", "display -p ", []string{"UART0"}},
+		{shell_state{}, "display -p SPI1 -r S", "display -p SPI1 -r ", []string{"SR"}},
+		{shell_state{}, "decode spi1.c", "decode ", []string{"spi1.CR1", "spi1.CR2"}},
+		{shell_state{}, "encode SPI1.CR1 b", "encode SPI1.CR1 ", []string{"BR="}},
+		{shell_state{periph: "SPI1", reg: "CR1"}, "encode S", "encode ", []string{"SPE="}},
+	}
+
+	for _, tc := range tests {
+		head, completions, tail := tc.sh.complete(tc.line, len(tc.line))
+		if head != tc.head || !slices.Equal(completions, tc.want) || tail != "" {
+			t.Errorf(`complete(%q) = %q, %q, %q, want %q, %q, ""`, tc.line, head, completions, tail, tc.head, tc.want)
+		}
+	}
+}
+
+func TestShellAddContext(t *testing.T) {
+	sh := shell_state{periph: "SPI1", reg: "CR1"}
+
+	tests := []struct {
+		words []string
+		want []string
+	}{
+		{[]string{"decode", "0x34C"}, []string{"decode", "0x34C", "-p", "SPI1", "-r", "CR1"}},
+		{[]string{"decode", "SPI2.CR1=5"}, []string{"decode", "SPI2.CR1=5"}},
+		{[]string{"display", "-p", "UART0"}, []string{"display", "-p", "UART0"}},
+		{[]string{"display", "-r", "SR"}, []string{"display", "-r", "SR", "-p", "SPI1"}},
+		{[]string{"display", "-pUART0"}, []string{"display", "-pUART0"}},
+		{[]string{"display", "-p=UART0"}, []string{"display", "-p=UART0"}},
+		{[]string{"display", "--peripheral", "UART0"}, []string{"display", "--peripheral", "UART0"}},
+		{[]string{"display", "--peripheral=UART0"}, []string{"display", "--peripheral=UART0"}},
+		{[]string{"display", "-vpUART0"}, []string{"display", "-vpUART0"}},
+		{[]string{"display", "-rSR"}, []string{"display", "-rSR", "-p", "SPI1"}},
+		{[]string{"display", "--field", "-rx"}, []string{"display", "--field", "-rx", "-p", "SPI1", "-r", "CR1"}},
+		{[]string{"display", "--exclude=-px"}, []string{"display", "--exclude=-px", "-p", "SPI1", "-r", "CR1"}},
+		{[]string{"list"}, []string{"list"}},
+	}
+
+	for _, tc := range tests {
+		if got := sh.add_context(slices.Clone(tc.words)); !slices.Equal(got, tc.want) {
+			t.Errorf(`add_context(%q) = %q, want %q`, tc.words, got, tc.want)
+		}
+	}
+}
+
+func TestShellResetFlags(t *testing.T) {
+	// as if the shell was started with -v -d test.db and display -p UART0 was run in it
+	defer func(v bool, d string) { verbose, database, shell_root_flags = v, d, nil }(verbose, database)
+	shell_root_flags = map[string]string{"verbose": "true", "database": "test.db", "curdir": "", "format": "text"}
+	if err := rootCmd.PersistentFlags().Set("verbose", "false"); err != nil {
+		t.Fatal(err)
+	}
+	if err := displayCmd.Flags().Set("peripheral", "UART0"); err != nil {
+		t.Fatal(err)
+	}
+
+	reset_flags(displayCmd)
+	if periph != "" || displayCmd.Flags().Changed("peripheral") {
+		t.Errorf(`reset_flags() left -p %q, want ""`, periph)
+	}
+	if !verbose || database != "test.db" || rootCmd.PersistentFlags().Changed("verbose") {
+		t.Errorf(`reset_flags() root flags are -v %v -d %q, want the ones the shell was started with`, verbose, database)
+	}
+}
@@ -0,0 +1,30 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// snapdiffCmd represents the snapdiff command
+var snapdiffCmd = &cobra.Command{
+	Use:   "snapdiff before after [--base address]",
+	Short: "Compare two register snapshots field by field",
+	Long: `Compare two memory dumps and report the registers and fields whose values differ
+	The dump files can be in any of the formats accepted by annotate, OpenOCD mdw or GDB x/NNwx output
+	or if --base is specified raw little endian binary files starting at that address
+	For each register that differs the fields that changed are shown with their old and new values
+	and the enumerated value names if known`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.SnapDiff(args[0], args[1], dump_base)
+	},
+}
+
+func init() {
+	snapdiffCmd.Flags().StringVar(&dump_base, "base", "", "Base address of raw binary dump files")
+
+	rootCmd.AddCommand(snapdiffCmd)
+}
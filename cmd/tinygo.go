@@ -0,0 +1,35 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+var tinygo_package string
+
+// tinygoCmd represents the tinygo command
+var tinygoCmd = &cobra.Command{
+	Use:   "tinygo [--package name] [--peripheral pattern]...",
+	Short: "Generate a TinyGo device package for the peripherals",
+	Long: `Generate go source for a TinyGo device package, a struct for each peripheral type
+	with the registers as volatile.Register32 (or Register16, Register8) at their offsets and _ padding between them,
+	registers at the same offset are one member named after all of them eg DLL_RBR_THR.
+	Each peripheral is a pointer to its type eg UART0.LCR.SetBits(UART0_LCR_WLS_Msk),
+	each field has _Pos and _Msk constants and each interrupt an IRQ_ constant.
+	The interrupts are only in databases converted with this version.
+	The package is named after the device unless --package is given, the output is gofmt clean
+	and passes go vet.` + select_help,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.GenTinyGo(gen_periphs, tinygo_package)
+	},
+}
+
+func init() {
+	add_select_flags(tinygoCmd)
+	tinygoCmd.Flags().StringVar(&tinygo_package, "package", "", "name of the generated package, default the device name")
+	rootCmd.AddCommand(tinygoCmd)
+}
@@ -0,0 +1,30 @@
+/*
+Copyright © 2026 Jim Morris <morris@wolfman.com>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	svd_lookup "github.com/wolfmanjm/svd_lookup/internal"
+)
+
+// zigCmd represents the zig command
+var zigCmd = &cobra.Command{
+	Use:   "zig [--peripheral pattern]...",
+	Short: "Generate zig packed structs for the peripherals",
+	Long: `Generate zig source with a packed struct(u32) for each register with fields,
+	the gaps between the fields are named reserved fields eg _reserved8 that default to 0.
+	Each peripheral type is an extern struct with the registers at their offsets and named reserved padding,
+	registers at the same offset are an extern union named after all of them eg DLL_RBR_THR.
+	Each peripheral is a volatile pointer to its type from @ptrFromInt eg UART0.LCR.WLS,
+	derived peripherals share the type of the one they are derived from.` + select_help,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return svd_lookup.GenZig(gen_periphs)
+	},
+}
+
+func init() {
+	add_select_flags(zigCmd)
+	rootCmd.AddCommand(zigCmd)
+}
@@ -0,0 +1,70 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// label every word of a memory dump with its register and decoded fields
+func Annotate(filename string, base string) error {
+	return annotate(os.Stdout, filename, base)
+}
+
+func annotate(out io.Writer, filename string, base string) error {
+	words, err := load_dump(filename, base)
+	if err != nil {
+		return err
+	}
+
+	am, err := build_address_map()
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		return words[i].addr < words[j].addr
+	})
+
+	fmt.Fprintln(out, "Annotated dump of", filename, "for MPU:", getMPU())
+
+	current := "-"
+	for _, w := range words {
+		locs := am.regs[w.addr]
+
+		// group by peripheral
+		pname := am.periph_at(w.addr)
+		if len(locs) > 0 {
+			pname = locs[0].periph
+		}
+		if pname != current {
+			if pname == "" {
+				fmt.Fprintln(out, "\nOutside any known peripheral")
+			} else {
+				fmt.Fprintln(out, "\nPeripheral", pname)
+			}
+			current = pname
+		}
+
+		if len(locs) == 0 {
+			fmt.Fprintf(out, "  0x%08X: 0x%08X ** not a known register **\n", w.addr, w.value)
+			continue
+		}
+
+		// there may be more than one register at the same address
+		for _, l := range locs {
+			s := fmt.Sprintf("  0x%08X: 0x%08X %v offset: %v", w.addr, w.value, l.reg.name, l.reg.address_offset)
+			if l.periph != pname {
+				s = fmt.Sprintf("  0x%08X: 0x%08X %v.%v offset: %v", w.addr, w.value, l.periph, l.reg.name, l.reg.address_offset)
+			}
+			if verbose && l.reg.description.Valid {
+				s += " - " + l.reg.description.V
+			}
+			fmt.Fprintln(out, s)
+			print_decoded(out, l.reg, w.value, "      ")
+		}
+	}
+
+	return nil
+}
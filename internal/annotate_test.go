@@ -0,0 +1,38 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	// the words are sorted by address, UART0 LCR has a reserved bit set
+	dump := write_dump(t, t.TempDir(), "dump.txt", `> mdw 0x50000000 1
+0x50000000: 00000001
+> mdw 0x4000C00C 1
+0x4000c00c: 0000011b
+0x4000c0fc: 00000000
+`)
+
+	var b bytes.Buffer
+	if err := annotate(&b, dump, ""); err != nil {
+		t.Fatalf(`annotate() = %v, want nil`, err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"\nPeripheral UART0\n  0x4000C00C: 0x0000011B LCR offset: 0x00C\n",
+		"      WLS[1:0]: 0x3 (3) 8_BIT_CHARACTER_LENG, differs from reset: 0x0\n",
+		"      DLAB[7]: 0x0 (0) DISABLE_ACCESS_TO_DI\n",
+		"      WARNING reserved bits set: 0x00000100\n",
+		"\nOutside any known peripheral\n  0x4000C0FC: 0x00000000 ** not a known register **\n",
+		"\nPeripheral EMAC\n  0x50000000: 0x00000001 MAC1 offset: 0x000\n      RXENABLE[0]: 0x1 (1), differs from reset: 0x0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("annotate() does not contain %q\n%v", want, out)
+		}
+	}
+	if strings.Index(out, "UART0") > strings.Index(out, "EMAC") {
+		t.Errorf("annotate() is not sorted by address\n%v", out)
+	}
+}
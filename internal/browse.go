@@ -0,0 +1,447 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// full screen terminal browser of the peripherals, registers and fields
+
+// what a node in the tree refers to, the peripheral registers are collected when it is first expanded
+type browse_item struct {
+	pr *Peripheral
+	reg *Register
+	field *Field
+}
+
+type browser struct {
+	app *tview.Application
+	screen tcell.Screen
+	tree *tview.TreeView
+	details *tview.TextView
+	status *tview.TextView
+	search *tview.InputField
+	layout *tview.Flex
+}
+
+const browse_help = "Enter/→ expand  ← collapse  / search  n next  f copy forth  a copy asm  q quit"
+
+func new_browser(screen tcell.Screen) (*browser, error) {
+	b := &browser{app: tview.NewApplication(), screen: screen}
+
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+
+	root := tview.NewTreeNode(getMPU()).SetSelectable(false)
+	for i := range periphs {
+		root.AddChild(tview.NewTreeNode(periphs[i].name).SetReference(&browse_item{pr: &periphs[i]}))
+	}
+
+	b.tree = tview.NewTreeView().SetRoot(root).SetTopLevel(1)
+	b.tree.SetBorder(true).SetTitle(" Peripherals ")
+	b.details = tview.NewTextView().SetWrap(true).SetWordWrap(true)
+	b.details.SetBorder(true).SetTitle(" Details ")
+	b.status = tview.NewTextView().SetText(browse_help)
+	b.search = tview.NewInputField().SetLabel("/")
+
+	b.tree.SetChangedFunc(func(node *tview.TreeNode) {
+		b.show_details(node)
+	})
+	b.tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		b.toggle(node)
+	})
+	b.tree.SetInputCapture(b.tree_keys)
+
+	b.search.SetChangedFunc(func(text string) {
+		b.find(text, false)
+	})
+	b.search.SetDoneFunc(func(key tcell.Key) {
+		b.layout.RemoveItem(b.search)
+		b.layout.AddItem(b.status, 1, 0, false)
+		b.app.SetFocus(b.tree)
+	})
+
+	main := tview.NewFlex().
+		AddItem(b.tree, 0, 1, true).
+		AddItem(b.details, 0, 2, false)
+	b.layout = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(main, 0, 1, true).
+		AddItem(b.status, 1, 0, false)
+
+	if len(periphs) > 0 {
+		b.tree.SetCurrentNode(root.GetChildren()[0])
+		b.show_details(root.GetChildren()[0])
+	}
+
+	if screen != nil {
+		b.app.SetScreen(screen)
+	}
+	b.app.SetRoot(b.layout, true)
+
+	return b, nil
+}
+
+func (b *browser) tree_keys(event *tcell.EventKey) *tcell.EventKey {
+	node := b.tree.GetCurrentNode()
+
+	switch event.Key() {
+	case tcell.KeyRight:
+		if node != nil && (!node.IsExpanded() || len(node.GetChildren()) == 0) {
+			b.toggle(node)
+		}
+		return nil
+	case tcell.KeyLeft:
+		if node == nil {
+			return nil
+		}
+		if node.IsExpanded() && len(node.GetChildren()) > 0 {
+			node.Collapse()
+		} else if parent := b.parent(node); parent != nil {
+			b.tree.SetCurrentNode(parent)
+			b.show_details(parent)
+		}
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'q':
+		b.app.Stop()
+		return nil
+	case '/':
+		b.layout.RemoveItem(b.status)
+		b.layout.AddItem(b.search.SetText(""), 1, 0, true)
+		b.app.SetFocus(b.search)
+		return nil
+	case 'n':
+		b.find(b.search.GetText(), true)
+		return nil
+	case 'f':
+		b.copy(node, "forth")
+		return nil
+	case 'a':
+		b.copy(node, "asm")
+		return nil
+	}
+
+	return event
+}
+
+// expand or collapse the node, collecting the registers of a peripheral the first time
+func (b *browser) toggle(node *tview.TreeNode) {
+	if node == nil {
+		return
+	}
+	if len(node.GetChildren()) > 0 {
+		node.SetExpanded(!node.IsExpanded())
+		return
+	}
+
+	if err := b.load_children(node); err != nil {
+		b.status.SetText(err.Error())
+		return
+	}
+	node.SetExpanded(true)
+}
+
+func (b *browser) load_children(node *tview.TreeNode) error {
+	item, ok := node.GetReference().(*browse_item)
+	if !ok || len(node.GetChildren()) > 0 {
+		return nil
+	}
+
+	switch {
+	case item.field != nil:
+		return nil
+
+	case item.reg != nil:
+		if item.reg.fields != nil {
+			for i := range *item.reg.fields {
+				f := &(*item.reg.fields)[i]
+				node.AddChild(tview.NewTreeNode(f.name).SetReference(&browse_item{pr: item.pr, reg: item.reg, field: f}))
+			}
+		}
+
+	default:
+		pr, err := collect_registers(item.pr.name)
+		if err != nil {
+			return fmt.Errorf("Failed to collect registers for peripheral %v: %w", item.pr.name, err)
+		}
+		item.pr = &pr
+		if pr.registers != nil {
+			for i := range *pr.registers {
+				r := &(*pr.registers)[i]
+				node.AddChild(tview.NewTreeNode(r.name).SetReference(&browse_item{pr: item.pr, reg: r}).SetExpanded(false))
+			}
+		}
+	}
+
+	node.SetExpanded(false)
+	return nil
+}
+
+func (b *browser) parent(node *tview.TreeNode) *tview.TreeNode {
+	var found *tview.TreeNode
+	b.tree.GetRoot().Walk(func(n, parent *tview.TreeNode) bool {
+		if n == node && parent != b.tree.GetRoot() {
+			found = parent
+		}
+		return found == nil
+	})
+	return found
+}
+
+// select the first node containing text, after the current node if next is set
+// registers are searched in the peripherals that have been expanded, or in the one named by periph.reg
+func (b *browser) find(text string, next bool) {
+	if text == "" {
+		return
+	}
+	lt := strings.ToLower(text)
+
+	if p, _, found := strings.Cut(lt, "."); found {
+		b.tree.GetRoot().Walk(func(n, parent *tview.TreeNode) bool {
+			if parent == b.tree.GetRoot() && strings.ToLower(n.GetText()) == p {
+				b.load_children(n)
+				n.SetExpanded(true)
+			}
+			return parent == nil
+		})
+	}
+
+	var nodes []*tview.TreeNode
+	b.tree.GetRoot().Walk(func(n, parent *tview.TreeNode) bool {
+		if parent != nil {
+			nodes = append(nodes, n)
+		}
+		return n.IsExpanded()
+	})
+
+	start := 0
+	if next {
+		for i, n := range nodes {
+			if n == b.tree.GetCurrentNode() {
+				start = i + 1
+			}
+		}
+	}
+
+	for i := range nodes {
+		n := nodes[(start+i)%len(nodes)]
+		if strings.Contains(strings.ToLower(browse_path(n)), lt) {
+			b.tree.SetCurrentNode(n)
+			b.show_details(n)
+			return
+		}
+	}
+}
+
+// the name to search, registers are periph.reg so they can be found that way
+func browse_path(n *tview.TreeNode) string {
+	item, ok := n.GetReference().(*browse_item)
+	if !ok {
+		return n.GetText()
+	}
+	switch {
+	case item.field != nil:
+		return item.pr.name + "." + item.reg.name + "." + item.field.name
+	case item.reg != nil:
+		return item.pr.name + "." + item.reg.name
+	default:
+		return item.pr.name
+	}
+}
+
+// copy the forth or asm for the item to the clipboard, it is also shown in the status line
+func (b *browser) copy(node *tview.TreeNode, lang string) {
+	if node == nil {
+		return
+	}
+	item, ok := node.GetReference().(*browse_item)
+	if !ok {
+		return
+	}
+
+	s := item_snippet(item, lang)
+	if b.screen != nil {
+		b.screen.SetClipboard([]byte(s))
+	}
+	b.status.SetText(fmt.Sprintf("copied %v: %v", lang, strings.ReplaceAll(s, "\n", "  ")))
+}
+
+// the same lines as the forth constants and asm generators output for the item
+func item_snippet(item *browse_item, lang string) string {
+	switch {
+	case item.field != nil:
+		if lang == "forth" {
+			return forth_field_line(*item.pr, *item.reg, *item.field)
+		}
+		return strings.Join(asm_field_lines(*item.reg, *item.field), "\n")
+	case item.reg != nil:
+		if lang == "forth" {
+			return forth_reg_line(*item.pr, *item.reg)
+		}
+		return asm_reg_line(*item.reg)
+	default:
+		if lang == "forth" {
+			return forth_base_line(*item.pr)
+		}
+		return asm_base_line(*item.pr)
+	}
+}
+
+func (b *browser) show_details(node *tview.TreeNode) {
+	if node == nil {
+		return
+	}
+	item, ok := node.GetReference().(*browse_item)
+	if !ok {
+		return
+	}
+	b.details.SetText(item_details(item)).ScrollToBeginning()
+	b.status.SetText(browse_help)
+}
+
+// the text for the details pane
+func item_details(item *browse_item) string {
+	var s strings.Builder
+	pr := item.pr
+
+	switch {
+	case item.field != nil:
+		f := item.field
+		r := item.reg
+		fmt.Fprintf(&s, "Field %v.%v.%v\n\n", pr.name, r.name, f.name)
+		fmt.Fprintf(&s, "Bits:   %v\n", bit_range(*f))
+		fmt.Fprintf(&s, "Width:  %v\n", f.num_bits)
+		fmt.Fprintf(&s, "Mask:   0x%08X\n", field_mask(*f))
+		if reset, err := parse_number(r.reset_value.V); r.reset_value.Valid && err == nil {
+			fmt.Fprintf(&s, "Reset:  0x%X\n", (reset & field_mask(*f)) >> f.bit_offset)
+		}
+		if f.description.Valid {
+			fmt.Fprintf(&s, "\n%v\n", clean_description(f.description.V))
+		}
+		if f.enums != nil && len(*f.enums) > 0 {
+			fmt.Fprintf(&s, "\nEnumerated values\n")
+			for _, e := range *f.enums {
+				fmt.Fprintf(&s, "  0x%X %v", e.value, e.name)
+				if e.description.Valid {
+					fmt.Fprintf(&s, " - %v", clean_description(e.description.V))
+				}
+				fmt.Fprintln(&s)
+			}
+		}
+
+	case item.reg != nil:
+		r := item.reg
+		fmt.Fprintf(&s, "Register %v.%v\n\n", pr.name, r.name)
+		base, _ := parse_number(pr.base_address)
+		off, _ := parse_number(r.address_offset)
+		fmt.Fprintf(&s, "Address: 0x%08X\n", base + off)
+		fmt.Fprintf(&s, "Offset:  %v\n", r.address_offset)
+		fmt.Fprintf(&s, "Reset:   %v\n", r.reset_value.V)
+		if r.description.Valid {
+			fmt.Fprintf(&s, "\n%v\n", clean_description(r.description.V))
+		}
+		fmt.Fprintf(&s, "\n%v", bit_diagram(*r))
+		if r.fields != nil {
+			fmt.Fprintln(&s)
+			for _, f := range *r.fields {
+				fmt.Fprintf(&s, "  %-8v %v\n", bit_range(f), f.name)
+			}
+		}
+
+	default:
+		fmt.Fprintf(&s, "Peripheral %v\n\n", pr.name)
+		fmt.Fprintf(&s, "Base address: %v\n", pr.base_address)
+		if pr.block_size.Valid {
+			fmt.Fprintf(&s, "Block size:   %v\n", pr.block_size.V)
+		}
+		if pr.derived_from.Valid {
+			if dp, err := fetch_peripheral(pr.derived_from.V); err == nil {
+				fmt.Fprintf(&s, "Derived from: %v\n", dp.name)
+			}
+		}
+		if pr.registers != nil {
+			fmt.Fprintf(&s, "Registers:    %v\n", len(*pr.registers))
+		}
+		if pr.description.Valid {
+			fmt.Fprintf(&s, "\n%v\n", clean_description(pr.description.V))
+		}
+	}
+
+	return s.String()
+}
+
+// a diagram of the 32 bits of the register showing where the fields are and the reset value of each bit
+func bit_diagram(r Register) string {
+	var s strings.Builder
+
+	owner := make([]string, 32)
+	if r.fields != nil {
+		for _, f := range *r.fields {
+			for i := f.bit_offset; i < f.bit_offset+f.num_bits && i < 32; i++ {
+				owner[i] = f.name
+			}
+		}
+	}
+	reset, err := parse_number(r.reset_value.V)
+	has_reset := r.reset_value.Valid && err == nil
+
+	for _, hi := range []int{31, 15} {
+		// bit numbers
+		for i := hi; i > hi-16; i-- {
+			fmt.Fprintf(&s, "%3d", i)
+		}
+		fmt.Fprintln(&s)
+
+		// field names centered in the bits they cover
+		for i := hi; i > hi-16; {
+			j := i
+			for j > hi-15 && owner[j-1] == owner[i] {
+				j--
+			}
+			width := 3*(i-j+1) - 1
+			name := owner[i]
+			if name == "" {
+				name = "-"
+			}
+			if len(name) > width {
+				name = name[:width]
+			}
+			pad := width - len(name)
+			fmt.Fprintf(&s, "|%v%v%v", strings.Repeat(" ", pad/2), name, strings.Repeat(" ", pad-pad/2))
+			i = j - 1
+		}
+		fmt.Fprintln(&s, "|")
+
+		// reset value of each bit
+		if has_reset {
+			for i := hi; i > hi-16; i-- {
+				fmt.Fprintf(&s, "%3d", (reset >> i) & 1)
+			}
+			fmt.Fprintln(&s)
+		}
+	}
+
+	return s.String()
+}
+
+// full screen browser of the peripherals, registers and fields
+func Browse() error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return fmt.Errorf("Unable to open the terminal - %w", err)
+	}
+
+	b, err := new_browser(screen)
+	if err != nil {
+		return err
+	}
+
+	return b.app.Run()
+}
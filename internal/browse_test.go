@@ -0,0 +1,114 @@
+package svd_lookup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// the text on the simulated screen
+func screen_text(s tcell.SimulationScreen) string {
+	cells, w, h := s.GetContents()
+	var sb strings.Builder
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := cells[y*w+x]
+			if len(c.Runes) > 0 {
+				sb.WriteRune(c.Runes[0])
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func TestBrowse(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	screen.SetSize(120, 40)
+
+	b, err := new_browser(screen)
+	if err != nil {
+		t.Fatalf(`new_browser() = %v, want nil`, err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- b.app.Run()
+	}()
+
+	// wait for each batch of keys to be handled before looking at the screen
+	sync := func() {
+		ch := make(chan bool)
+		b.app.QueueUpdateDraw(func() { close(ch) })
+		<-ch
+		b.app.QueueUpdateDraw(func() {})
+	}
+	keys := func(s string) {
+		for _, r := range s {
+			b.app.QueueEvent(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+		}
+		sync()
+	}
+	key := func(k tcell.Key) {
+		b.app.QueueEvent(tcell.NewEventKey(k, 0, tcell.ModNone))
+		sync()
+	}
+
+	// incremental search for periph.reg expands the peripheral and selects the register
+	keys("/uart0.lcr")
+	key(tcell.KeyEnter)
+
+	text := screen_text(screen)
+	for _, want := range []string{"Register UART0.LCR", "Address: 0x4000C00C", "WLS"} {
+		if !strings.Contains(text, want) {
+			t.Errorf(`browse after searching uart0.lcr does not show %q:\n%v`, want, text)
+		}
+	}
+
+	// expand the register and select the first field, which has enums
+	key(tcell.KeyRight)
+	key(tcell.KeyDown)
+	text = screen_text(screen)
+	for _, want := range []string{"Field UART0.LCR.WLS", "Enumerated values", "8_BIT_CHARACTER_LENG"} {
+		if !strings.Contains(text, want) {
+			t.Errorf(`browse on the LCR field does not show %q:\n%v`, want, text)
+		}
+	}
+
+	// copy the forth for the field
+	keys("f")
+	clip := string(screen.GetClipboardData())
+	if clip != "$00000003 0 2constant m_uart0_LCR_WLS" {
+		t.Errorf(`browse copy forth = %q, want $00000003 0 2constant m_uart0_LCR_WLS`, clip)
+	}
+
+	keys("q")
+	if err := <-done; err != nil {
+		t.Errorf(`browse run = %v, want nil`, err)
+	}
+}
+
+func TestBitDiagram(t *testing.T) {
+	pr, err := collect_registers("UART0")
+	if err != nil {
+		t.Fatalf(`collect_registers("UART0") = %v, want nil`, err)
+	}
+	r, err := find_register(pr, "LCR")
+	if err != nil {
+		t.Fatalf(`find_register(UART0, LCR) = %v, want nil`, err)
+	}
+
+	lines := strings.Split(bit_diagram(r), "\n")
+	if len(lines) < 6 {
+		t.Fatalf(`bit_diagram(LCR) has %v lines, want 6:\n%v`, len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), "31") || !strings.HasSuffix(lines[3], " 0") {
+		t.Errorf(`bit_diagram(LCR) bit numbers are wrong:\n%v`, strings.Join(lines, "\n"))
+	}
+	if !strings.Contains(lines[4], "WLS") || !strings.HasSuffix(lines[4], "|") {
+		t.Errorf(`bit_diagram(LCR) does not show the WLS field:\n%v`, strings.Join(lines, "\n"))
+	}
+}
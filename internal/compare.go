@@ -0,0 +1,303 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"sort"
+)
+
+// compare a peripheral on two chips to help port a driver between them, eg SPI0 on the RP2040 and RP2350
+// the registers are lined up by name, or by offset if they were renamed, and each field is classified
+
+type FieldComparison struct {
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status" yaml:"status"`
+	Old    string `json:"old,omitempty" yaml:"old,omitempty"`
+	New    string `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+type RegisterComparison struct {
+	Name      string            `json:"name" yaml:"name"`
+	NewName   string            `json:"new_name,omitempty" yaml:"new_name,omitempty"`
+	Status    string            `json:"status" yaml:"status"`
+	OldOffset *Hex              `json:"old_offset,omitempty" yaml:"old_offset,omitempty"`
+	NewOffset *Hex              `json:"new_offset,omitempty" yaml:"new_offset,omitempty"`
+	Fields    []FieldComparison `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+type PeripheralComparison struct {
+	Peripheral string               `json:"peripheral" yaml:"peripheral"`
+	Old        string               `json:"old" yaml:"old"`
+	New        string               `json:"new" yaml:"new"`
+	OldBase    Hex                  `json:"old_base" yaml:"old_base"`
+	NewBase    Hex                  `json:"new_base" yaml:"new_base"`
+	Registers  []RegisterComparison `json:"registers" yaml:"registers"`
+	ForthValid bool                 `json:"forth_valid" yaml:"forth_valid"`
+	Reasons    []string             `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+}
+
+// registers are same, moved, renamed (at the same offset with a different name), new or removed
+// fields are one of these, a field that moved and changed width is resized
+var field_statuses = []string{"identical", "moved", "resized", "new", "removed"}
+
+// load the named peripheral with its registers from the database file, and the MPU name
+func load_peripheral(fn string, name string) (PeripheralInfo, string, error) {
+	var pi PeripheralInfo
+	var mpu string
+	err := with_database(fn, func() error {
+		p, err := fetch_peripheral_by_name(name)
+		if err != nil {
+			return fmt.Errorf("Peripheral %v not found in %v: %w", name, fn, err)
+		}
+		regs, err := fetch_registers_with_fields(p)
+		if err != nil {
+			return fmt.Errorf("Failed to collect registers for peripheral %v: %w", p.name, err)
+		}
+		if pi, err = peripheral_info_with(p, regs); err != nil {
+			return err
+		}
+		mpu = getMPU()
+		return nil
+	})
+	return pi, mpu, err
+}
+
+// same format as bit_range
+func field_bits(f FieldInfo) string {
+	if f.NumBits == 1 {
+		return fmt.Sprintf("[%v]", f.BitOffset)
+	}
+	return fmt.Sprintf("[%v:%v]", f.BitOffset+f.NumBits-1, f.BitOffset)
+}
+
+func by_offset(regs []RegisterInfo) []RegisterInfo {
+	sorted := append([]RegisterInfo{}, regs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].AddressOffset < sorted[j].AddressOffset
+	})
+	return sorted
+}
+
+func compare_fields(old []FieldInfo, new []FieldInfo) []FieldComparison {
+	nm := make(map[string]FieldInfo)
+	for _, f := range new {
+		nm[f.Name] = f
+	}
+
+	var fcs []FieldComparison
+	seen := make(map[string]bool)
+	for _, of := range old {
+		nf, ok := nm[of.Name]
+		fc := FieldComparison{Name: of.Name, Old: field_bits(of)}
+		switch {
+		case !ok:
+			fc.Status = "removed"
+		case nf.NumBits != of.NumBits:
+			fc.Status = "resized"
+		case nf.BitOffset != of.BitOffset:
+			fc.Status = "moved"
+		default:
+			fc.Status = "identical"
+		}
+		if ok {
+			fc.New = field_bits(nf)
+			seen[of.Name] = true
+		}
+		fcs = append(fcs, fc)
+	}
+
+	for _, nf := range new {
+		if !seen[nf.Name] {
+			fcs = append(fcs, FieldComparison{Name: nf.Name, Status: "new", New: field_bits(nf)})
+		}
+	}
+
+	return fcs
+}
+
+// line up the registers by name, or by offset when the name is not in the new peripheral
+func compare_registers(old []RegisterInfo, new []RegisterInfo) []RegisterComparison {
+	old = by_offset(old)
+	new = by_offset(new)
+
+	old_names := make(map[string]bool)
+	for _, r := range old {
+		old_names[r.Name] = true
+	}
+	by_name := make(map[string]RegisterInfo)
+	at_offset := make(map[Hex]RegisterInfo)
+	for _, r := range new {
+		by_name[r.Name] = r
+		// only registers that are not in the old peripheral can be a rename
+		if _, ok := at_offset[r.AddressOffset]; !ok && !old_names[r.Name] {
+			at_offset[r.AddressOffset] = r
+		}
+	}
+
+	var rcs []RegisterComparison
+	matched := make(map[string]bool)
+	for _, or := range old {
+		rc := RegisterComparison{Name: or.Name, OldOffset: &or.AddressOffset}
+		nr, ok := by_name[or.Name]
+		if ok {
+			rc.Status = "same"
+			if nr.AddressOffset != or.AddressOffset {
+				rc.Status = "moved"
+			}
+		} else if nr, ok = at_offset[or.AddressOffset]; ok && !matched[nr.Name] {
+			rc.Status = "renamed"
+			rc.NewName = nr.Name
+		} else {
+			ok = false
+			rc.Status = "removed"
+		}
+
+		if ok {
+			matched[nr.Name] = true
+			rc.NewOffset = &nr.AddressOffset
+			rc.Fields = compare_fields(or.Fields, nr.Fields)
+		} else {
+			rc.Fields = compare_fields(or.Fields, nil)
+		}
+		rcs = append(rcs, rc)
+	}
+
+	for _, nr := range new {
+		if !matched[nr.Name] {
+			rcs = append(rcs, RegisterComparison{Name: nr.Name, Status: "new", NewOffset: &nr.AddressOffset, Fields: compare_fields(nil, nr.Fields)})
+		}
+	}
+
+	return rcs
+}
+
+// compare the peripheral on the old chip with the new one
+// the forth words generated for the old chip are still valid if the base address, the register offsets and the
+// field positions and widths they use have not changed, new registers and fields just do not have words
+func compare_peripherals(old PeripheralInfo, new PeripheralInfo) PeripheralComparison {
+	pc := PeripheralComparison{Peripheral: old.Name, OldBase: old.BaseAddress, NewBase: new.BaseAddress}
+	pc.Registers = compare_registers(old.Registers, new.Registers)
+
+	if old.BaseAddress != new.BaseAddress {
+		pc.Reasons = append(pc.Reasons, fmt.Sprintf("the base address changed from %v to %v", hex_string(old.BaseAddress), hex_string(new.BaseAddress)))
+	}
+	for _, rc := range pc.Registers {
+		switch rc.Status {
+		case "moved":
+			pc.Reasons = append(pc.Reasons, fmt.Sprintf("register %v moved from %v to %v", rc.Name, hex_string(*rc.OldOffset), hex_string(*rc.NewOffset)))
+		case "removed":
+			pc.Reasons = append(pc.Reasons, fmt.Sprintf("register %v was removed", rc.Name))
+			continue
+		case "new":
+			continue
+		}
+		for _, fc := range rc.Fields {
+			switch fc.Status {
+			case "moved", "resized":
+				pc.Reasons = append(pc.Reasons, fmt.Sprintf("field %v.%v %v from %v to %v", rc.Name, fc.Name, fc.Status, fc.Old, fc.New))
+			case "removed":
+				pc.Reasons = append(pc.Reasons, fmt.Sprintf("field %v.%v was removed", rc.Name, fc.Name))
+			}
+		}
+	}
+	pc.ForthValid = len(pc.Reasons) == 0
+
+	return pc
+}
+
+func optional_offset_string(h *Hex) string {
+	if h == nil {
+		return ""
+	}
+	return fmt.Sprintf("0x%03X", uint64(*h))
+}
+
+// compare the peripheral in the two database files
+func Compare(dbs []string, periph string) error {
+	if len(dbs) != 2 {
+		return fmt.Errorf("Two databases must be given to compare, got %v", len(dbs))
+	}
+
+	old, old_mpu, err := load_peripheral(dbs[0], periph)
+	if err != nil {
+		return err
+	}
+	new, new_mpu, err := load_peripheral(dbs[1], periph)
+	if err != nil {
+		return err
+	}
+
+	pc := compare_peripherals(old, new)
+	pc.Old = old_mpu
+	pc.New = new_mpu
+
+	if !text_output() {
+		var rows [][]string
+		for _, rc := range pc.Registers {
+			for _, fc := range rc.Fields {
+				rows = append(rows, []string{rc.Name, rc.NewName, rc.Status, optional_offset_string(rc.OldOffset),
+					optional_offset_string(rc.NewOffset), fc.Name, fc.Status, fc.Old, fc.New})
+			}
+			if len(rc.Fields) == 0 {
+				rows = append(rows, []string{rc.Name, rc.NewName, rc.Status, optional_offset_string(rc.OldOffset),
+					optional_offset_string(rc.NewOffset), "", "", "", ""})
+			}
+		}
+		return write_structured(pc, []string{"register", "new_register", "register_status", "old_offset", "new_offset",
+			"field", "field_status", "old_bits", "new_bits"}, rows)
+	}
+
+	fmt.Printf("Comparing %v on %v (%v) with %v (%v)\n", periph, old_mpu, dbs[0], new_mpu, dbs[1])
+	if pc.OldBase == pc.NewBase {
+		fmt.Printf("base address %v\n", hex_string(pc.OldBase))
+	} else {
+		fmt.Printf("base address %v -> %v\n", hex_string(pc.OldBase), hex_string(pc.NewBase))
+	}
+
+	counts := make(map[string]int)
+	for _, rc := range pc.Registers {
+		switch rc.Status {
+		case "same":
+			fmt.Printf("%v %v\n", rc.Name, optional_offset_string(rc.OldOffset))
+		case "moved":
+			fmt.Printf("%v %v -> %v moved\n", rc.Name, optional_offset_string(rc.OldOffset), optional_offset_string(rc.NewOffset))
+		case "renamed":
+			fmt.Printf("%v %v renamed to %v\n", rc.Name, optional_offset_string(rc.OldOffset), rc.NewName)
+		case "new":
+			fmt.Printf("%v %v new\n", rc.Name, optional_offset_string(rc.NewOffset))
+		case "removed":
+			fmt.Printf("%v %v removed\n", rc.Name, optional_offset_string(rc.OldOffset))
+		}
+
+		for _, fc := range rc.Fields {
+			counts[fc.Status]++
+			switch fc.Status {
+			case "moved", "resized":
+				fmt.Printf("  %v %v -> %v %v\n", fc.Name, fc.Old, fc.New, fc.Status)
+			case "new":
+				fmt.Printf("  %v %v new\n", fc.Name, fc.New)
+			default:
+				fmt.Printf("  %v %v %v\n", fc.Name, fc.Old, fc.Status)
+			}
+		}
+	}
+
+	fmt.Print("Fields:")
+	for i, s := range field_statuses {
+		if i > 0 {
+			fmt.Print(",")
+		}
+		fmt.Printf(" %v %v", counts[s], s)
+	}
+	fmt.Println()
+
+	if pc.ForthValid {
+		fmt.Printf("The forth words generated for %v on %v are still valid on %v\n", periph, old_mpu, new_mpu)
+	} else {
+		fmt.Printf("The forth words generated for %v on %v must be regenerated for %v:\n", periph, old_mpu, new_mpu)
+		for _, r := range pc.Reasons {
+			fmt.Println("  " + r)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package svd_lookup
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComparePeripherals(t *testing.T) {
+	old := PeripheralInfo{Name: "SPI0", BaseAddress: 0x1000, Registers: []RegisterInfo{
+		{Name: "CR1", AddressOffset: 4, Fields: []FieldInfo{{Name: "SSE", BitOffset: 1, NumBits: 1}, {Name: "MS", BitOffset: 2, NumBits: 1}}},
+		{Name: "CR0", AddressOffset: 0, Fields: []FieldInfo{
+			{Name: "DSS", BitOffset: 0, NumBits: 4}, {Name: "SCR", BitOffset: 8, NumBits: 8}, {Name: "SPO", BitOffset: 6, NumBits: 1},
+			{Name: "SPH", BitOffset: 7, NumBits: 1},
+		}},
+		{Name: "DR", AddressOffset: 8, Fields: []FieldInfo{{Name: "DATA", BitOffset: 0, NumBits: 16}}},
+		{Name: "SR", AddressOffset: 0xC},
+		{Name: "OLD", AddressOffset: 0x20},
+	}}
+	new := PeripheralInfo{Name: "SPI0", BaseAddress: 0x1000, Registers: []RegisterInfo{
+		{Name: "CR0", AddressOffset: 0, Fields: []FieldInfo{
+			{Name: "DSS", BitOffset: 0, NumBits: 5}, {Name: "SCR", BitOffset: 8, NumBits: 8}, {Name: "SPO", BitOffset: 5, NumBits: 1},
+			{Name: "FRF", BitOffset: 4, NumBits: 1},
+		}},
+		{Name: "CR1", AddressOffset: 4, Fields: []FieldInfo{{Name: "SSE", BitOffset: 1, NumBits: 1}, {Name: "MS", BitOffset: 2, NumBits: 1}}},
+		{Name: "DATA", AddressOffset: 8, Fields: []FieldInfo{{Name: "DATA", BitOffset: 0, NumBits: 16}}},
+		{Name: "SR", AddressOffset: 0x10},
+		{Name: "ICR", AddressOffset: 0x24},
+	}}
+
+	pc := compare_peripherals(old, new)
+
+	var regs []string
+	for _, rc := range pc.Registers {
+		regs = append(regs, rc.Name+" "+rc.Status+" "+rc.NewName)
+	}
+	want := []string{"CR0 same ", "CR1 same ", "DR renamed DATA", "SR moved ", "OLD removed ", "ICR new "}
+	if !slices.Equal(regs, want) {
+		t.Errorf(`compare_peripherals() registers = %v, want %v`, regs, want)
+	}
+
+	var fields []string
+	for _, fc := range pc.Registers[0].Fields {
+		fields = append(fields, fc.Name+" "+fc.Status)
+	}
+	want = []string{"DSS resized", "SCR identical", "SPO moved", "SPH removed", "FRF new"}
+	if !slices.Equal(fields, want) {
+		t.Errorf(`compare_peripherals() CR0 fields = %v, want %v`, fields, want)
+	}
+	if pc.Registers[2].Fields[0].Status != "identical" {
+		t.Errorf(`compare_peripherals() DR.DATA = %v, want identical`, pc.Registers[2].Fields[0].Status)
+	}
+
+	if pc.ForthValid || len(pc.Reasons) != 5 {
+		t.Errorf(`compare_peripherals() forth valid %v reasons %v, want not valid with 5 reasons`, pc.ForthValid, pc.Reasons)
+	}
+
+	// a renamed register and new registers and fields do not stop the old words working
+	new.Registers = append(old.Registers[:2:2], RegisterInfo{Name: "DATA", AddressOffset: 8}, old.Registers[3], old.Registers[4], new.Registers[4])
+	new.Registers[2].Fields = old.Registers[2].Fields
+	new.Registers[0].Fields = append(new.Registers[0].Fields, FieldInfo{Name: "LBM", BitOffset: 0, NumBits: 1})
+	pc = compare_peripherals(old, new)
+	if !pc.ForthValid {
+		t.Errorf(`compare_peripherals() with additions = %v, want forth valid`, pc.Reasons)
+	}
+
+	new.BaseAddress = 0x2000
+	pc = compare_peripherals(old, new)
+	if pc.ForthValid || len(pc.Reasons) != 1 {
+		t.Errorf(`compare_peripherals() with a new base = %v %v, want just the base to differ`, pc.ForthValid, pc.Reasons)
+	}
+}
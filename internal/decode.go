@@ -0,0 +1,167 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// the decoded value of one field in a register
+type field_value struct {
+	field Field
+	value uint64
+	reset uint64
+	has_reset bool
+}
+
+// mask for the field in its position in the register
+func field_mask(f Field) uint64 {
+	return uint64(IntPow(2, f.num_bits) - 1) << f.bit_offset
+}
+
+// the name of the enumerated value matching v, or "" if there is none
+func enum_name(f Field, v uint64) string {
+	if f.enums == nil {
+		return ""
+	}
+	for _, e := range *f.enums {
+		if e.value == v {
+			return e.name
+		}
+	}
+	return ""
+}
+
+// printable bit range of the field eg [5:3] or [6]
+func bit_range(f Field) string {
+	if f.num_bits == 1 {
+		return fmt.Sprintf("[%v]", f.bit_offset)
+	}
+	return fmt.Sprintf("[%v:%v]", f.bit_offset+f.num_bits-1, f.bit_offset)
+}
+
+// find the named register in the collected registers of the peripheral
+func find_register(pr Peripheral, name string) (Register, error) {
+	if pr.registers != nil {
+		for _, r := range *pr.registers {
+			if strings.EqualFold(r.name, name) {
+				return r, nil
+			}
+		}
+	}
+	return Register{}, fmt.Errorf("No register %v in peripheral %v", name, pr.name)
+}
+
+// some SVDs have fields explicitly named RESERVED
+func is_reserved(f Field) bool {
+	return strings.HasPrefix(strings.ToUpper(f.name), "RESERVED")
+}
+
+// the size of the register in bits, registers in older databases do not have a size and are 32 bits
+func register_size(r Register) int {
+	if r.size.Valid {
+		if v, err := parse_number(r.size.V); err == nil && v > 0 && v <= 64 {
+			return int(v)
+		}
+	}
+	return 32
+}
+
+// the value has to fit in the register, like encode does for the field values
+func check_register_value(r Register, v uint64, s string) error {
+	if n := register_size(r); n < 64 && v>>n != 0 {
+		return fmt.Errorf("value %v is too wide for register %v which is %v bits, max is 0x%X", s, r.name, n, uint64(1)<<n-1)
+	}
+	return nil
+}
+
+// mask of the bits in the register that are not covered by any field
+func reserved_mask(r Register) uint64 {
+	var covered uint64
+	if r.fields != nil {
+		for _, f := range *r.fields {
+			if !is_reserved(f) {
+				covered |= field_mask(f)
+			}
+		}
+	}
+	all := ^uint64(0)
+	if n := register_size(r); n < 64 {
+		all = uint64(1)<<n - 1
+	}
+	return ^covered & all
+}
+
+// split the value of the register into its fields
+func decode_register(r Register, value uint64) []field_value {
+	var fvs []field_value
+
+	reset, err := parse_number(r.reset_value.V)
+	has_reset := r.reset_value.Valid && err == nil
+
+	if r.fields != nil {
+		for _, f := range *r.fields {
+			if is_reserved(f) {
+				continue
+			}
+			mask := field_mask(f)
+			fv := field_value{field: f, value: (value & mask) >> f.bit_offset, has_reset: has_reset}
+			if has_reset {
+				fv.reset = (reset & mask) >> f.bit_offset
+			}
+			fvs = append(fvs, fv)
+		}
+	}
+
+	return fvs
+}
+
+// print out the fields of the decoded value, indented by indent
+func print_decoded(out io.Writer, r Register, value uint64, indent string) {
+	for _, fv := range decode_register(r, value) {
+		s := fmt.Sprintf("%v%v%v: 0x%X (%v)", indent, fv.field.name, bit_range(fv.field), fv.value, fv.value)
+		if en := enum_name(fv.field, fv.value); en != "" {
+			s += " " + en
+		}
+		if fv.has_reset && fv.value != fv.reset {
+			s += fmt.Sprintf(", differs from reset: 0x%X", fv.reset)
+		}
+		if verbose && fv.field.description.Valid {
+			s += " - " + fv.field.description.V
+		}
+		fmt.Fprintln(out, s)
+	}
+
+	if rsv := value & reserved_mask(r); rsv != 0 {
+		fmt.Fprintf(out, "%vWARNING reserved bits set: 0x%08X\n", indent, rsv)
+	}
+}
+
+// decode a register value into its fields
+func Decode(periph string, reg string, value string) error {
+	v, err := parse_number(value)
+	if err != nil {
+		return fmt.Errorf("Unable to parse value %v - %w", value, err)
+	}
+
+	// collects and populates all the registers and fields for this peripheral
+	pr, err := collect_registers(periph)
+	if err != nil {
+		return fmt.Errorf("Failed to collect registers for peripheral %v: %w", periph, err)
+	}
+
+	r, err := find_register(pr, reg)
+	if err != nil {
+		return err
+	}
+	if err := check_register_value(r, v, value); err != nil {
+		return err
+	}
+
+	fmt.Printf("Decode of %v.%v = 0x%08X for MPU: %v\n", pr.name, r.name, v, getMPU())
+	fmt.Printf("Register %v offset: %v, reset: %v\n", r.name, r.address_offset, r.reset_value.V)
+	print_decoded(os.Stdout, r, v, "    ")
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package svd_lookup
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestDecodeRegister(t *testing.T) {
+	pr, err := collect_registers("UART0")
+	if err != nil {
+		t.Fatalf(`collect_registers("UART0") = %v, want nil`, err)
+	}
+	r, err := find_register(pr, "lcr")
+	if err != nil {
+		t.Fatalf(`find_register(UART0, "lcr") = %v, want nil`, err)
+	}
+
+	want := map[string]uint64{"WLS": 3, "SBS": 0, "PE": 1, "PS": 1, "BC": 0, "DLAB": 0}
+	fvs := decode_register(r, 0x1B)
+	if len(fvs) != len(want) {
+		t.Fatalf(`decode_register(LCR, 0x1B) returned %v fields, want %v`, len(fvs), len(want))
+	}
+	for _, fv := range fvs {
+		if fv.value != want[fv.field.name] {
+			t.Errorf(`decode_register(LCR, 0x1B) field %v = %v, want %v`, fv.field.name, fv.value, want[fv.field.name])
+		}
+		if fv.field.name == "WLS" && enum_name(fv.field, fv.value) != "8_BIT_CHARACTER_LENG" {
+			t.Errorf(`enum_name(WLS, 3) = %v, want 8_BIT_CHARACTER_LENG`, enum_name(fv.field, fv.value))
+		}
+	}
+
+	if m := reserved_mask(r); m != 0xFFFFFF00 {
+		t.Errorf(`reserved_mask(LCR) = 0x%08X, want 0xFFFFFF00`, m)
+	}
+}
+
+func TestReservedMaskSize(t *testing.T) {
+	fields := []Field{{BasicInfo: BasicInfo{name: "LO"}, num_bits: 4, bit_offset: 0}}
+	tests := []struct {
+		size sql.Null[string]
+		want uint64
+	}{
+		{sql.Null[string]{V: "8", Valid: true}, 0xF0},
+		{sql.Null[string]{V: "0x10", Valid: true}, 0xFFF0},
+		{sql.Null[string]{}, 0xFFFFFFF0},
+		{sql.Null[string]{V: "64", Valid: true}, 0xFFFFFFFFFFFFFFF0},
+	}
+	for _, tt := range tests {
+		r := Register{BasicInfo: BasicInfo{name: "R"}, size: tt.size, fields: &fields}
+		if m := reserved_mask(r); m != tt.want {
+			t.Errorf(`reserved_mask() with size %q = 0x%X, want 0x%X`, tt.size.V, m, tt.want)
+		}
+	}
+}
+
+func TestDecodeTooWide(t *testing.T) {
+	if err := Decode("UART0", "LCR", "0xFFFFFFFFF"); err == nil {
+		t.Errorf(`Decode(UART0, LCR, 0xFFFFFFFFF) = nil, want error`)
+	}
+
+	r := Register{BasicInfo: BasicInfo{name: "R"}, size: sql.Null[string]{V: "8", Valid: true}}
+	if err := check_register_value(r, 0xFF, "0xFF"); err != nil {
+		t.Errorf(`check_register_value(8 bits, 0xFF) = %v, want nil`, err)
+	}
+	if err := check_register_value(r, 0x100, "0x100"); err == nil {
+		t.Errorf(`check_register_value(8 bits, 0x100) = nil, want error`)
+	}
+}
+
+func TestFindRegisterMissing(t *testing.T) {
+	pr, _ := collect_registers("UART0")
+	if _, err := find_register(pr, "NOTHERE"); err == nil {
+		t.Errorf(`find_register(UART0, "NOTHERE") = nil, want error`)
+	}
+}
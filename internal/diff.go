@@ -0,0 +1,291 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"sort"
+)
+
+// compare two databases, eg two revisions of the same chip, and report the peripherals, registers,
+// fields and enumerated values that were added, removed or changed
+
+type Change struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	What      string `json:"what" yaml:"what"`
+	Path      string `json:"path" yaml:"path"`
+	Attribute string `json:"attribute,omitempty" yaml:"attribute,omitempty"`
+	Old       string `json:"old,omitempty" yaml:"old,omitempty"`
+	New       string `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+type DiffResult struct {
+	Old     string   `json:"old" yaml:"old"`
+	New     string   `json:"new" yaml:"new"`
+	Changes []Change `json:"changes" yaml:"changes"`
+}
+
+// a named attribute of an item with its value in each database
+type diff_attribute struct {
+	name string
+	old string
+	new string
+}
+
+type device_diff struct {
+	changes []Change
+}
+
+// open the database file, run f and close it again
+func with_database(fn string, f func() error) error {
+	SetDatabase(fn)
+	if err := OpenDatabase(); err != nil {
+		return err
+	}
+	defer CloseDatabase()
+	return f()
+}
+
+// load the whole device from the database file
+func load_device(fn string) (DeviceInfo, error) {
+	var d DeviceInfo
+	err := with_database(fn, func() error {
+		var err error
+		d, err = collect_device(nil)
+		return err
+	})
+	return d, err
+}
+
+func hex_string(h Hex) string {
+	return fmt.Sprintf("0x%X", uint64(h))
+}
+
+func optional_hex_string(h *Hex) string {
+	if h == nil {
+		return ""
+	}
+	return hex_string(*h)
+}
+
+func optional_int_string(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprint(n)
+}
+
+// the sorted union of the names in both sets
+func union_names[T any](a map[string]T, b map[string]T) []string {
+	var names []string
+	for n := range a {
+		names = append(names, n)
+	}
+	for n := range b {
+		if _, ok := a[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (dd *device_diff) add(kind string, what string, path string) {
+	dd.changes = append(dd.changes, Change{Kind: kind, What: what, Path: path})
+}
+
+func (dd *device_diff) attributes(what string, path string, attrs []diff_attribute) {
+	for _, a := range attrs {
+		if a.old != a.new {
+			dd.changes = append(dd.changes, Change{Kind: "changed", What: what, Path: path, Attribute: a.name, Old: a.old, New: a.new})
+		}
+	}
+}
+
+func (dd *device_diff) peripherals(old []PeripheralInfo, new []PeripheralInfo, scope []name_pattern) {
+	om := make(map[string]PeripheralInfo)
+	for _, p := range old {
+		om[p.Name] = p
+	}
+	nm := make(map[string]PeripheralInfo)
+	for _, p := range new {
+		nm[p.Name] = p
+	}
+
+	for _, name := range union_names(om, nm) {
+		if !match_any(scope, name) {
+			continue
+		}
+		op, in_old := om[name]
+		np, in_new := nm[name]
+		switch {
+		case !in_new:
+			dd.add("removed", "peripheral", name)
+		case !in_old:
+			dd.add("added", "peripheral", name)
+		default:
+			dd.attributes("peripheral", name, []diff_attribute{
+				{"base_address", hex_string(op.BaseAddress), hex_string(np.BaseAddress)},
+				{"derived_from", op.DerivedFrom, np.DerivedFrom},
+			})
+			// the changes to the registers are reported for the peripheral they are derived from,
+			// unless that is not in scope when they are reported for this one
+			if op.DerivedFrom != "" && op.DerivedFrom == np.DerivedFrom {
+				ob, in_old := om[op.DerivedFrom]
+				nb, in_new := nm[np.DerivedFrom]
+				if !match_any(scope, op.DerivedFrom) && in_old && in_new {
+					dd.registers(name, ob.Registers, nb.Registers)
+				}
+				continue
+			}
+			dd.registers(name, op.Registers, np.Registers)
+		}
+	}
+}
+
+func (dd *device_diff) registers(periph string, old []RegisterInfo, new []RegisterInfo) {
+	om := make(map[string]RegisterInfo)
+	for _, r := range old {
+		om[r.Name] = r
+	}
+	nm := make(map[string]RegisterInfo)
+	for _, r := range new {
+		nm[r.Name] = r
+	}
+
+	for _, name := range union_names(om, nm) {
+		path := periph + "." + name
+		or, in_old := om[name]
+		nr, in_new := nm[name]
+		switch {
+		case !in_new:
+			dd.add("removed", "register", path)
+		case !in_old:
+			dd.add("added", "register", path)
+		default:
+			dd.attributes("register", path, []diff_attribute{
+				{"address_offset", hex_string(or.AddressOffset), hex_string(nr.AddressOffset)},
+				{"size", optional_int_string(or.Size), optional_int_string(nr.Size)},
+				{"reset_value", optional_hex_string(or.ResetValue), optional_hex_string(nr.ResetValue)},
+				{"access", or.Access, nr.Access},
+			})
+			dd.fields(path, or.Fields, nr.Fields)
+		}
+	}
+}
+
+func (dd *device_diff) fields(reg string, old []FieldInfo, new []FieldInfo) {
+	om := make(map[string]FieldInfo)
+	for _, f := range old {
+		om[f.Name] = f
+	}
+	nm := make(map[string]FieldInfo)
+	for _, f := range new {
+		nm[f.Name] = f
+	}
+
+	for _, name := range union_names(om, nm) {
+		path := reg + "." + name
+		of, in_old := om[name]
+		nf, in_new := nm[name]
+		switch {
+		case !in_new:
+			dd.add("removed", "field", path)
+		case !in_old:
+			dd.add("added", "field", path)
+		default:
+			dd.attributes("field", path, []diff_attribute{
+				{"bit_offset", fmt.Sprint(of.BitOffset), fmt.Sprint(nf.BitOffset)},
+				{"num_bits", fmt.Sprint(of.NumBits), fmt.Sprint(nf.NumBits)},
+				{"access", of.Access, nf.Access},
+			})
+			dd.enums(path, of.Enums, nf.Enums)
+		}
+	}
+}
+
+func (dd *device_diff) enums(field string, old []EnumInfo, new []EnumInfo) {
+	om := make(map[string]EnumInfo)
+	for _, e := range old {
+		om[e.Name] = e
+	}
+	nm := make(map[string]EnumInfo)
+	for _, e := range new {
+		nm[e.Name] = e
+	}
+
+	for _, name := range union_names(om, nm) {
+		path := field + "." + name
+		oe, in_old := om[name]
+		ne, in_new := nm[name]
+		switch {
+		case !in_new:
+			dd.add("removed", "enum", path)
+		case !in_old:
+			dd.add("added", "enum", path)
+		default:
+			dd.attributes("enum", path, []diff_attribute{{"value", fmt.Sprint(oe.Value), fmt.Sprint(ne.Value)}})
+		}
+	}
+}
+
+// the differences between the two devices, limited to the peripherals matching any of the patterns
+func diff_devices(old DeviceInfo, new DeviceInfo, periph_pats []string) ([]Change, error) {
+	scope, err := compile_patterns(periph_pats)
+	if err != nil {
+		return nil, err
+	}
+
+	var dd device_diff
+	dd.peripherals(old.Peripherals, new.Peripherals, scope)
+	return dd.changes, nil
+}
+
+var change_marks = map[string]string{"added": "+", "removed": "-", "changed": "~"}
+
+// compare the old database with the new one and report the differences
+func Diff(old_db string, new_db string, periph_pats []string) error {
+	old, err := load_device(old_db)
+	if err != nil {
+		return err
+	}
+	new, err := load_device(new_db)
+	if err != nil {
+		return err
+	}
+
+	changes, err := diff_devices(old, new, periph_pats)
+	if err != nil {
+		return err
+	}
+
+	if !text_output() {
+		var rows [][]string
+		for _, c := range changes {
+			rows = append(rows, []string{c.Kind, c.What, c.Path, c.Attribute, c.Old, c.New})
+		}
+		if changes == nil {
+			changes = []Change{}
+		}
+		return write_structured(DiffResult{Old: old.Name, New: new.Name, Changes: changes},
+			[]string{"kind", "what", "path", "attribute", "old", "new"}, rows)
+	}
+
+	fmt.Printf("Differences from %v (%v) to %v (%v)\n", old_db, old.Name, new_db, new.Name)
+	for _, c := range changes {
+		if c.Kind == "changed" {
+			fmt.Printf("%v %v %v %v %v -> %v\n", change_marks[c.Kind], c.What, c.Path, c.Attribute, or_none(c.Old), or_none(c.New))
+		} else {
+			fmt.Printf("%v %v %v\n", change_marks[c.Kind], c.What, c.Path)
+		}
+	}
+	fmt.Printf("%v differences\n", len(changes))
+
+	return nil
+}
+
+// attributes missing from one of the databases
+func or_none(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+package svd_lookup
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffDevices(t *testing.T) {
+	reset := Hex(0)
+	old := DeviceInfo{Name: "OLD", Peripherals: []PeripheralInfo{
+		{Name: "SPI1", BaseAddress: 0x1000, Registers: []RegisterInfo{
+			{Name: "CR1", AddressOffset: 0, Size: 32, ResetValue: &reset, Access: "read-write", Fields: []FieldInfo{
+				{Name: "BR", BitOffset: 3, NumBits: 3, Enums: []EnumInfo{{Name: "DIV2", Value: 0}, {Name: "DIV4", Value: 1}}},
+				{Name: "SPE", BitOffset: 6, NumBits: 1},
+			}},
+			{Name: "I2SCFGR", AddressOffset: 0x1C},
+		}},
+		{Name: "SPI2", BaseAddress: 0x2000, DerivedFrom: "SPI1"},
+		{Name: "USART1", BaseAddress: 0x3000},
+	}}
+	new_reset := Hex(4)
+	new := DeviceInfo{Name: "NEW", Peripherals: []PeripheralInfo{
+		{Name: "SPI1", BaseAddress: 0x1000, Registers: []RegisterInfo{
+			{Name: "CR1", AddressOffset: 0, Size: 32, ResetValue: &new_reset, Access: "read-write", Fields: []FieldInfo{
+				{Name: "BR", BitOffset: 3, NumBits: 4, Enums: []EnumInfo{{Name: "DIV2", Value: 0}, {Name: "DIV8", Value: 2}}},
+				{Name: "SPE", BitOffset: 6, NumBits: 1, Access: "write-only"},
+			}},
+			{Name: "CR2", AddressOffset: 4},
+		}},
+		{Name: "SPI2", BaseAddress: 0x2400, DerivedFrom: "SPI1"},
+		{Name: "SPI6", BaseAddress: 0x6000},
+	}}
+
+	changes, err := diff_devices(old, new, nil)
+	if err != nil {
+		t.Fatalf(`diff_devices() = %v, want nil`, err)
+	}
+	want := []Change{
+		{Kind: "changed", What: "register", Path: "SPI1.CR1", Attribute: "reset_value", Old: "0x0", New: "0x4"},
+		{Kind: "changed", What: "field", Path: "SPI1.CR1.BR", Attribute: "num_bits", Old: "3", New: "4"},
+		{Kind: "removed", What: "enum", Path: "SPI1.CR1.BR.DIV4"},
+		{Kind: "added", What: "enum", Path: "SPI1.CR1.BR.DIV8"},
+		{Kind: "changed", What: "field", Path: "SPI1.CR1.SPE", Attribute: "access", Old: "", New: "write-only"},
+		{Kind: "added", What: "register", Path: "SPI1.CR2"},
+		{Kind: "removed", What: "register", Path: "SPI1.I2SCFGR"},
+		{Kind: "changed", What: "peripheral", Path: "SPI2", Attribute: "base_address", Old: "0x2000", New: "0x2400"},
+		{Kind: "added", What: "peripheral", Path: "SPI6"},
+		{Kind: "removed", What: "peripheral", Path: "USART1"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("diff_devices() =\n%v\nwant\n%v", changes, want)
+	}
+
+	// only the peripherals in scope are compared
+	changes, err = diff_devices(old, new, []string{"spi1", "USART*"})
+	if err != nil || len(changes) != 8 || changes[0].Path != "SPI1.CR1" || changes[7].Path != "USART1" {
+		t.Errorf(`diff_devices(spi1, USART*) = %v, %v, want the SPI1 and USART1 changes`, changes, err)
+	}
+
+	// the register changes of a derived peripheral are reported for it when the one it is derived from is not in scope
+	changes, err = diff_devices(old, new, []string{"spi2"})
+	want = append([]Change{want[7]}, want[:7]...)
+	for i := 1; i < len(want); i++ {
+		want[i].Path = "SPI2" + want[i].Path[len("SPI1"):]
+	}
+	if err != nil || !slices.Equal(changes, want) {
+		t.Errorf("diff_devices(spi2) =\n%v, %v\nwant\n%v", changes, err, want)
+	}
+
+	if _, err := diff_devices(old, new, []string{"re:("}); err == nil {
+		t.Errorf(`diff_devices("re:(") = nil, want an error`)
+	}
+
+	if changes, _ := diff_devices(old, old, nil); len(changes) != 0 {
+		t.Errorf(`diff_devices(old, old) = %v, want no changes`, changes)
+	}
+}
+
+func TestRegisterSizeAndAccess(t *testing.T) {
+	pr, err := collect_registers("UART0")
+	if err != nil {
+		t.Fatalf(`collect_registers("UART0") = %v, want nil`, err)
+	}
+	pi, err := peripheral_info_with(pr, *pr.registers)
+	if err != nil {
+		t.Fatalf(`peripheral_info_with(UART0) = %v, want nil`, err)
+	}
+	i := slices.IndexFunc(pi.Registers, func(r RegisterInfo) bool { return r.Name == "LCR" })
+	if i < 0 {
+		t.Fatalf(`UART0 has no LCR register`)
+	}
+	lcr := pi.Registers[i]
+	if lcr.Size != 32 || lcr.Access != "read-write" || len(lcr.Fields) == 0 || lcr.Fields[0].Access != "read-write" {
+		t.Errorf(`UART0.LCR size %v access %v fields %v, want 32 read-write and the fields read-write`, lcr.Size, lcr.Access, lcr.Fields)
+	}
+}
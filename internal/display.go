@@ -2,66 +2,85 @@ package svd_lookup
 
 import (
 	"fmt"
-	"slices"
-	"strings"
 )
 
-func Display(periph string, reg_pat string) (error) {
-	fmt.Println("Registers and fields for Peripheral:", periph, " for MPU:", getMPU())
-
+func Display(periph string, filt Filter) (error) {
 	p, err := fetch_peripheral_by_name(periph)
 	if err != nil {
 		return fmt.Errorf("No peripheral with name like %v - %w", periph, err)
 	}
 
-	fmt.Printf("%v base address: %v\n", p.name, p.base_address);
+	// collects and populates all the registers and fields for this peripheral
+	pr, err := collect_registers(p.name)
+	if err != nil {
+		return fmt.Errorf("Failed to collect registers for peripheral %v: %w", p.name, err)
+	}
 
-	id := p.id
+	var regs []Register
+	if pr.registers != nil {
+		// filter out registers and fields if required
+		regs = filt.apply(*pr.registers)
+	}
+
+	if !text_output() {
+		return write_peripheral(p, regs)
+	}
+
+	fmt.Println("Registers and fields for Peripheral:", periph, " for MPU:", getMPU())
+	fmt.Printf("%v base address: %v\n", p.name, p.base_address);
 
 	if p.derived_from.Valid {
-		id = p.derived_from.V
-		np, _ := fetch_peripheral(id)
+		np, _ := fetch_peripheral(p.derived_from.V)
 		fmt.Println("Has the same registers as", np.name)
 	}
 
-	// collects and populates all the registers and fields for this peripheral
-	pr, err := collect_registers(p.name)
-	if err != nil {
-		return fmt.Errorf("Failed to collect registers for peripheral %v: %w", p.name, err)
+	// print out
+	for _, r := range regs {
+		print_register(r)
 	}
 
-	// print out
-	if pr.registers != nil {
-		regs := *pr.registers
+	return nil
+}
 
-		if reg_pat != "" {
-			// filter out registers if required
-			regs = slices.DeleteFunc(regs, func(n Register) bool {
-				return !strings.Contains(strings.ToLower(n.name), strings.ToLower(reg_pat))
-			})
-		}
+// print out the register and its fields
+func print_register(r Register) {
+	s := fmt.Sprintf("Register %v offset: %v, reset: %v", r.name, r.address_offset, r.reset_value.V)
+	if verbose && r.description.Valid {
+		s += " - " + r.description.V
+	}
+	fmt.Println(s)
 
-		for _, r := range regs {
-			s := fmt.Sprintf("Register %v offset: %v, reset: %v", r.name, r.address_offset, r.reset_value.V)
-			if verbose && r.description.Valid {
-				s += " - " + r.description.V
-			}
-			fmt.Println(s)
-
-			// print out the fields for this register
-			if r.fields != nil {
-				for _, f := range *r.fields  {
-					desc := ""
-					if verbose && f.description.Valid {
-						desc = " - " + f.description.V
-					}
-					mask := (IntPow(2, f.num_bits) - 1) << f.bit_offset
-					fmt.Printf("    %v: number bits %v, bit offset: %v, mask: 0x%08X %s\n", f.name, f.num_bits, f.bit_offset, mask, desc)
-				}
+	// print out the fields for this register
+	if r.fields != nil {
+		for _, f := range *r.fields  {
+			desc := ""
+			if verbose && f.description.Valid {
+				desc = " - " + f.description.V
 			}
+			mask := (IntPow(2, f.num_bits) - 1) << f.bit_offset
+			fmt.Printf("    %v: number bits %v, bit offset: %v, mask: 0x%08X %s\n", f.name, f.num_bits, f.bit_offset, mask, desc)
 		}
 	}
+}
+
+// display just the one named register of the peripheral
+func Show(periph string, reg string) (error) {
+	pr, err := collect_registers(periph)
+	if err != nil {
+		return fmt.Errorf("Failed to collect registers for peripheral %v: %w", periph, err)
+	}
+
+	r, err := find_register(pr, reg)
+	if err != nil {
+		return err
+	}
+
+	if !text_output() {
+		return write_peripheral(pr, []Register{r})
+	}
+
+	fmt.Printf("%v base address: %v\n", pr.name, pr.base_address);
+	print_register(r)
 
 	return nil
 }
-
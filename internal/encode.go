@@ -0,0 +1,168 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// a value to be written to one field of a register
+type field_assignment struct {
+	field Field
+	value uint64
+}
+
+// find the named field in the register
+func find_field(r Register, name string) (Field, error) {
+	if r.fields != nil {
+		for _, f := range *r.fields {
+			if strings.EqualFold(f.name, name) {
+				return f, nil
+			}
+		}
+	}
+	return Field{}, fmt.Errorf("No field %v in register %v", name, r.name)
+}
+
+// the value may be a number or the name of one of the fields enumerated values
+func field_value_of(f Field, s string) (uint64, error) {
+	if f.enums != nil {
+		for _, e := range *f.enums {
+			if strings.EqualFold(e.name, s) {
+				return e.value, nil
+			}
+		}
+	}
+
+	v, err := parse_number(s)
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a number or an enumerated value of field %v", s, f.name)
+	}
+
+	if maxv := uint64(IntPow(2, f.num_bits) - 1); v > maxv {
+		return 0, fmt.Errorf("value %v is too wide for field %v which is %v bits, max is 0x%X", s, f.name, f.num_bits, maxv)
+	}
+
+	return v, nil
+}
+
+// parse the list of field=value assignments for the register
+func parse_assignments(r Register, assigns []string) ([]field_assignment, error) {
+	var fas []field_assignment
+	seen := make(map[string]bool)
+
+	for _, a := range assigns {
+		name, value, found := strings.Cut(a, "=")
+		if !found {
+			return nil, fmt.Errorf("%v is not of the form field=value", a)
+		}
+
+		f, err := find_field(r, name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[f.name] {
+			return nil, fmt.Errorf("field %v is assigned more than once", f.name)
+		}
+		seen[f.name] = true
+
+		v, err := field_value_of(f, value)
+		if err != nil {
+			return nil, err
+		}
+		fas = append(fas, field_assignment{field: f, value: v})
+	}
+
+	return fas, nil
+}
+
+// combine the field assignments into the register value and the write mask
+func encode_register(fas []field_assignment) (uint64, uint64) {
+	var value, mask uint64
+	for _, fa := range fas {
+		value |= fa.value << fa.field.bit_offset
+		mask |= field_mask(fa.field)
+	}
+	return value, mask
+}
+
+// encode the field assignments into a register value and mask, and print the code to write it
+func Encode(periph string, reg string, assigns []string) error {
+	// collects and populates all the registers and fields for this peripheral
+	pr, err := collect_registers(periph)
+	if err != nil {
+		return fmt.Errorf("Failed to collect registers for peripheral %v: %w", periph, err)
+	}
+
+	r, err := find_register(pr, reg)
+	if err != nil {
+		return err
+	}
+
+	fas, err := parse_assignments(r, assigns)
+	if err != nil {
+		return err
+	}
+
+	value, mask := encode_register(fas)
+
+	fmt.Printf("Encode of %v.%v for MPU: %v\n", pr.name, r.name, getMPU())
+	for _, fa := range fas {
+		s := fmt.Sprintf("    %v%v = 0x%X", fa.field.name, bit_range(fa.field), fa.value)
+		if en := enum_name(fa.field, fa.value); en != "" {
+			s += " " + en
+		}
+		fmt.Println(s)
+	}
+	fmt.Printf("value: 0x%08X\n", value)
+	fmt.Printf("mask:  0x%08X\n", mask)
+
+	// forth using the words from GenForthConsts and GenForthRegs
+	prefix := strings.ToLower(pr.name)
+	reg_const := forth_const_reg_name(pr.name, r.name)
+	fmt.Println("\n\\ forth")
+	fmt.Printf("$%08X $%08X 0 %v modify-reg\n", value, mask, reg_const)
+	for _, fa := range fas {
+		fmt.Println(forth_field_write(forth_field_name(prefix, r.name, fa.field), fa, reg_const))
+	}
+	fmt.Println("\\ forth --freg")
+	reg_word := pr.name + " " + forth_freg_reg_name(pr.name, r.name)
+	for _, fa := range fas {
+		fmt.Println(forth_field_write(forth_field_name("", r.name, fa.field), fa, reg_word))
+	}
+
+	// asm using the equates from GenAsm
+	var vals, masks []string
+	for _, fa := range fas {
+		if fa.field.num_bits == 1 {
+			if fa.value != 0 {
+				vals = append(vals, asm_field_name(r.name, fa.field))
+			}
+		} else {
+			vals = append(vals, fmt.Sprintf("(%v<<%v)", fa.value, asm_offset_name(r.name, fa.field)))
+		}
+		masks = append(masks, asm_field_name(r.name, fa.field))
+	}
+	if len(vals) == 0 {
+		vals = append(vals, "0")
+	}
+	fmt.Println("\n; asm")
+	fmt.Printf(".equ %v_%v_VALUE, %v\n", pr.name, r.name, strings.Join(vals, " | "))
+	fmt.Printf(".equ %v_%v_MASK, %v\n", pr.name, r.name, strings.Join(masks, " | "))
+
+	// C
+	fmt.Println("\n// C")
+	fmt.Printf("%v->%v = (%v->%v & ~0x%08XU) | 0x%08XU;\n", pr.name, r.name, pr.name, r.name, mask, value)
+
+	return nil
+}
+
+// forth to write one field, single bits use bis! or bic! the others use modify-reg
+func forth_field_write(name string, fa field_assignment, reg string) string {
+	if fa.field.num_bits == 1 {
+		if fa.value != 0 {
+			return fmt.Sprintf("%v %v bis!", name, reg)
+		}
+		return fmt.Sprintf("%v %v bic!", name, reg)
+	}
+	return fmt.Sprintf("%v %v %v modify-reg", fa.value, name, reg)
+}
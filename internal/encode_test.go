@@ -0,0 +1,35 @@
+package svd_lookup
+
+import (
+	"testing"
+)
+
+func TestEncodeRegister(t *testing.T) {
+	pr, err := collect_registers("UART0")
+	if err != nil {
+		t.Fatalf(`collect_registers("UART0") = %v, want nil`, err)
+	}
+	r, _ := find_register(pr, "LCR")
+
+	fas, err := parse_assignments(r, []string{"WLS=8_BIT_CHARACTER_LENG", "pe=1", "PS=0b01"})
+	if err != nil {
+		t.Fatalf(`parse_assignments() = %v, want nil`, err)
+	}
+
+	value, mask := encode_register(fas)
+	if value != 0x1B || mask != 0x3B {
+		t.Errorf(`encode_register() = 0x%X, 0x%X, want 0x1B, 0x3B`, value, mask)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	pr, _ := collect_registers("UART0")
+	r, _ := find_register(pr, "LCR")
+
+	bad := [][]string{{"WLS=4"}, {"NOTHERE=1"}, {"WLS"}, {"PE=1", "PE=0"}, {"WLS=NOT_AN_ENUM"}}
+	for _, a := range bad {
+		if _, err := parse_assignments(r, a); err == nil {
+			t.Errorf(`parse_assignments(%v) = nil, want error`, a)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// the filtering of registers and fields shared by display and the generators so they all select the same subset
+// a pattern is either
+//   re:regex   a regular expression matched anywhere in the name
+//   glob       if it has any of * ? [ it is matched against the whole name eg CR* or *_ISR
+//   text       otherwise it matches names containing the text
+// all matching ignores case
+
+type name_pattern struct {
+	re *regexp.Regexp
+	glob string
+	text string
+}
+
+type Filter struct {
+	registers []name_pattern
+	exclude []name_pattern
+	fields []name_pattern
+}
+
+func compile_pattern(pat string) (name_pattern, error) {
+	switch {
+	case strings.HasPrefix(pat, "re:"):
+		re, err := regexp.Compile("(?i)" + pat[3:])
+		if err != nil {
+			return name_pattern{}, fmt.Errorf("Bad regex %v - %w", pat[3:], err)
+		}
+		return name_pattern{re: re}, nil
+
+	case strings.ContainsAny(pat, "*?["):
+		if _, err := path.Match(pat, ""); err != nil {
+			return name_pattern{}, fmt.Errorf("Bad glob pattern %v - %w", pat, err)
+		}
+		return name_pattern{glob: strings.ToLower(pat)}, nil
+
+	default:
+		return name_pattern{text: strings.ToLower(pat)}, nil
+	}
+}
+
+func compile_patterns(pats []string) ([]name_pattern, error) {
+	var nps []name_pattern
+	for _, p := range pats {
+		if p == "" {
+			continue
+		}
+		np, err := compile_pattern(p)
+		if err != nil {
+			return nil, err
+		}
+		nps = append(nps, np)
+	}
+	return nps, nil
+}
+
+func (np name_pattern) match(name string) bool {
+	switch {
+	case np.re != nil:
+		return np.re.MatchString(name)
+	case np.glob != "":
+		m, _ := path.Match(np.glob, strings.ToLower(name))
+		return m
+	default:
+		return strings.Contains(strings.ToLower(name), np.text)
+	}
+}
+
+// true if any of the patterns match, or there are no patterns
+func match_any(nps []name_pattern, name string) bool {
+	for _, np := range nps {
+		if np.match(name) {
+			return true
+		}
+	}
+	return len(nps) == 0
+}
+
+// a filter selecting registers matching any of regs and none of exclude, and the fields matching any of fields
+// empty lists select everything
+func NewFilter(regs []string, exclude []string, fields []string) (Filter, error) {
+	var f Filter
+	var err error
+	if f.registers, err = compile_patterns(regs); err != nil {
+		return f, err
+	}
+	if f.exclude, err = compile_patterns(exclude); err != nil {
+		return f, err
+	}
+	if f.fields, err = compile_patterns(fields); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func (filt Filter) match_register(name string) bool {
+	return match_any(filt.registers, name) && (len(filt.exclude) == 0 || !match_any(filt.exclude, name))
+}
+
+func (filt Filter) match_field(name string) bool {
+	return match_any(filt.fields, name)
+}
+
+// the selected registers with just the selected fields, when filtering on fields the registers with none are dropped
+// the registers passed in are not changed
+func (filt Filter) apply(regs []Register) []Register {
+	var selected []Register
+	for _, r := range regs {
+		if !filt.match_register(r.name) {
+			continue
+		}
+		if len(filt.fields) > 0 {
+			var fields []Field
+			if r.fields != nil {
+				for _, f := range *r.fields {
+					if filt.match_field(f.name) {
+						fields = append(fields, f)
+					}
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			r.fields = &fields
+		}
+		selected = append(selected, r)
+	}
+	return selected
+}
@@ -0,0 +1,62 @@
+package svd_lookup
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	pr, err := collect_registers("TIMER0")
+	if err != nil {
+		t.Fatalf(`collect_registers("TIMER0") = %v, want nil`, err)
+	}
+
+	tests := []struct {
+		regs []string
+		exclude []string
+		fields []string
+		want []string
+	}{
+		{nil, nil, nil, []string{"CCR", "CR[%s]", "CTCR", "EMR", "IR", "MCR", "MR[%s]", "PC", "PR", "TC", "TCR"}},
+		{[]string{"cr"}, nil, nil, []string{"CCR", "CR[%s]", "CTCR", "MCR", "TCR"}},
+		{[]string{"T?R", "p*"}, nil, nil, []string{"PC", "PR", "TCR"}},
+		{[]string{"re:^(ir|emr)$"}, nil, nil, []string{"EMR", "IR"}},
+		{[]string{"cr"}, []string{"re:^c", "tcr"}, nil, []string{"MCR"}},
+		{nil, nil, []string{"MR0?"}, []string{"MCR"}},
+		{nil, nil, []string{"MR0"}, []string{"IR", "MCR"}},
+	}
+
+	for _, tc := range tests {
+		filt, err := NewFilter(tc.regs, tc.exclude, tc.fields)
+		if err != nil {
+			t.Fatalf(`NewFilter(%q, %q, %q) = %v, want nil`, tc.regs, tc.exclude, tc.fields, err)
+		}
+		var names []string
+		for _, r := range filt.apply(*pr.registers) {
+			names = append(names, r.name)
+		}
+		if !slices.Equal(names, tc.want) {
+			t.Errorf(`filter %q, %q, %q = %q, want %q`, tc.regs, tc.exclude, tc.fields, names, tc.want)
+		}
+	}
+
+	// only the matching fields are kept, without changing the collected registers
+	filt, _ := NewFilter([]string{"MCR"}, nil, []string{"re:^MR0"})
+	regs := filt.apply(*pr.registers)
+	var fields []string
+	for _, f := range *regs[0].fields {
+		fields = append(fields, f.name)
+	}
+	if !slices.Equal(fields, []string{"MR0I", "MR0R", "MR0S"}) {
+		t.Errorf(`MCR fields filtered by re:^MR0 = %q, want MR0I MR0R MR0S`, fields)
+	}
+	if r, _ := find_register(pr, "MCR"); len(*r.fields) <= 3 {
+		t.Errorf(`filtering changed the fields of the collected MCR register`)
+	}
+
+	for _, bad := range []string{"re:(", "CR["} {
+		if _, err := NewFilter([]string{bad}, nil, nil); err == nil {
+			t.Errorf(`NewFilter(%q) = nil, want an error`, bad)
+		}
+	}
+}
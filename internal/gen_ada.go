@@ -0,0 +1,386 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// generate an ada package spec for the device in the style of svd2ada, a record for each register with a
+// representation clause placing the fields, enumeration types for fields with enumerated values, a record for each
+// peripheral type with the registers at their offsets and an object for each peripheral at its base address
+// everything is in the one package so the names are prefixed with the peripheral type eg UART0_LCR_Register
+
+var ada_keywords = []string{"abort", "abs", "abstract", "accept", "access", "aliased", "all", "and", "array", "at",
+	"begin", "body", "case", "constant", "declare", "delay", "delta", "digits", "do", "else", "elsif", "end", "entry",
+	"exception", "exit", "for", "function", "generic", "goto", "if", "in", "interface", "is", "limited", "loop", "mod",
+	"new", "not", "null", "of", "or", "others", "out", "overriding", "package", "parallel", "pragma", "private",
+	"procedure", "protected", "raise", "range", "record", "rem", "renames", "requeue", "return", "reverse", "select",
+	"separate", "some", "subtype", "synchronized", "tagged", "task", "terminate", "then", "type", "until", "use", "when",
+	"while", "with", "xor"}
+
+var ada_underscores = regexp.MustCompile(`_+`)
+
+// the name as an ada identifier, which can not start with a digit or _ or have two _ together,
+// keywords have _k added eg MOD is MOD_k
+func ada_name(name string) string {
+	s := strings.Trim(ada_underscores.ReplaceAllString(identifier(name), "_"), "_")
+	switch {
+	case s == "":
+		s = "Unnamed"
+	case s[0] >= '0' && s[0] <= '9':
+		s = "Val_" + s
+	case slices.Contains(ada_keywords, strings.ToLower(s)):
+		s += "_k"
+	}
+	return s
+}
+
+// ada is not case sensitive so names that only differ in case get a suffix to make them unique
+type ada_names map[string]bool
+
+func (an ada_names) unique(name string, suffix string) string {
+	if an[strings.ToLower(name)] {
+		name += "_" + suffix
+	}
+	an[strings.ToLower(name)] = true
+	return name
+}
+
+func ada_comment(w io.Writer, indent string, s string) {
+	if s = clean_description(s); s != "" {
+		fmt.Fprintf(w, "%v--  %v\n", indent, s)
+	}
+}
+
+// a section heading in the style of svd2ada
+func ada_banner(w io.Writer, s string) {
+	line := strings.Repeat("-", len(s)+6)
+	fmt.Fprintf(w, "   %v\n   -- %v --\n   %v\n\n", line, s, line)
+}
+
+func ada_number(v uint64) string {
+	return fmt.Sprintf("16#%X#", v)
+}
+
+func ada_uint(bits int) string {
+	return fmt.Sprintf("UInt%v", bits)
+}
+
+// an enumerated value of a field
+type ada_literal struct {
+	name  string
+	value uint64
+}
+
+// the enumeration literals of the field in value order, values that do not fit in the field or are repeated are left out
+func ada_literals(f FieldInfo) []ada_literal {
+	var lits []ada_literal
+	names := make(ada_names)
+	seen := make(map[uint64]bool)
+	enums := append([]EnumInfo{}, f.Enums...)
+	sort.SliceStable(enums, func(i, j int) bool {
+		return enums[i].Value < enums[j].Value
+	})
+	for _, e := range enums {
+		if seen[e.Value] || (f.NumBits < 64 && e.Value >= 1<<f.NumBits) {
+			continue
+		}
+		seen[e.Value] = true
+		lits = append(lits, ada_literal{names.unique(ada_name(e.Name), fmt.Sprint(e.Value)), e.Value})
+	}
+	return lits
+}
+
+// a field of a register record, or a reserved gap between them
+type ada_component struct {
+	name     string
+	typ      string
+	lo, hi   int
+	comment  string
+	dflt     string
+	literals []ada_literal
+}
+
+// the components of the register record, the reset value gives the defaults
+func ada_register_components(prefix string, r RegisterInfo) []ada_component {
+	fields, _ := packed_fields(r)
+	bits := register_bits(r)
+
+	var cs []ada_component
+	names := make(ada_names)
+	pos := 0
+	reserved := func(to int) {
+		if to > pos {
+			c := ada_component{name: names.unique(fmt.Sprintf("Reserved_%v_%v", pos, to-1), "r"), typ: ada_uint(to - pos), lo: pos, hi: to - 1}
+			if r.ResetValue != nil {
+				c.dflt = ada_number((uint64(*r.ResetValue) >> pos) & (1<<(to-pos) - 1))
+			}
+			cs = append(cs, c)
+		}
+	}
+	for _, f := range fields {
+		reserved(f.BitOffset)
+		c := ada_component{name: names.unique(ada_name(f.Name), fmt.Sprint(f.BitOffset)), typ: ada_uint(f.NumBits),
+			lo: f.BitOffset, hi: f.BitOffset + f.NumBits - 1, comment: f.Description, literals: ada_literals(f)}
+		if len(c.literals) > 0 {
+			c.typ = prefix + "_" + c.name + "_Field"
+		}
+		if r.ResetValue != nil {
+			v := (uint64(*r.ResetValue) & uint64(f.Mask)) >> f.BitOffset
+			c.dflt = ada_number(v)
+			if len(c.literals) > 0 {
+				c.dflt = ""
+				for _, l := range c.literals {
+					if l.value == v {
+						c.dflt = l.name
+					}
+				}
+			}
+		}
+		cs = append(cs, c)
+		pos = f.BitOffset + f.NumBits
+	}
+	reserved(bits)
+	return cs
+}
+
+// the enumeration types then the record for the register, prefix is eg UART0_LCR
+func ada_register_record(w io.Writer, prefix string, r RegisterInfo) {
+	bits := register_bits(r)
+	cs := ada_register_components(prefix, r)
+
+	for _, c := range cs {
+		if len(c.literals) == 0 {
+			continue
+		}
+		ada_comment(w, "   ", c.comment)
+		fmt.Fprintf(w, "   type %v is\n", c.typ)
+		for i, l := range c.literals {
+			sep := ","
+			if i == len(c.literals)-1 {
+				sep = ")"
+			}
+			if i == 0 {
+				fmt.Fprintf(w, "     (%v%v\n", l.name, sep)
+			} else {
+				fmt.Fprintf(w, "      %v%v\n", l.name, sep)
+			}
+		}
+		fmt.Fprintf(w, "     with Size => %v;\n", c.hi-c.lo+1)
+		fmt.Fprintf(w, "   for %v use\n", c.typ)
+		for i, l := range c.literals {
+			sep := ","
+			if i == len(c.literals)-1 {
+				sep = ");"
+			}
+			if i == 0 {
+				fmt.Fprintf(w, "     (%v => %v%v\n", l.name, l.value, sep)
+			} else {
+				fmt.Fprintf(w, "      %v => %v%v\n", l.name, l.value, sep)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	ada_comment(w, "   ", r.Description)
+	fmt.Fprintf(w, "   type %v_Register is record\n", prefix)
+	for _, c := range cs {
+		ada_comment(w, "      ", c.comment)
+		if c.dflt != "" {
+			fmt.Fprintf(w, "      %v : %v := %v;\n", c.name, c.typ, c.dflt)
+		} else {
+			fmt.Fprintf(w, "      %v : %v;\n", c.name, c.typ)
+		}
+	}
+	fmt.Fprintln(w, "   end record")
+	fmt.Fprintf(w, "     with Volatile_Full_Access, Object_Size => %v,\n", bits)
+	fmt.Fprintln(w, "          Bit_Order => System.Low_Order_First;")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "   for %v_Register use record\n", prefix)
+	for _, c := range cs {
+		fmt.Fprintf(w, "      %v at 0 range %v .. %v;\n", c.name, c.lo, c.hi)
+	}
+	fmt.Fprintln(w, "   end record;")
+	fmt.Fprintln(w)
+}
+
+// the register records and the peripheral record, registers at the same offset are in the variants of an unchecked union
+func ada_peripheral_type(w io.Writer, p PeripheralInfo) {
+	t := ada_name(type_name(p))
+	pl := layout_registers(p.Registers)
+
+	// the component name of each register
+	names := make(ada_names)
+	components := make(map[string]string)
+	for _, s := range pl.slots {
+		for _, r := range s.regs {
+			components[layout_key(r)] = names.unique(ada_name(pl.name(r)), fmt.Sprintf("%X", uint64(r.AddressOffset)))
+		}
+	}
+	component_type := func(r RegisterInfo) string {
+		if fields, _ := packed_fields(r); len(fields) > 0 {
+			return t + "_" + components[layout_key(r)] + "_Register"
+		}
+		return ada_uint(register_bits(r))
+	}
+
+	ada_banner(w, t+" Registers")
+
+	variants := 1
+	for _, s := range pl.slots {
+		variants = max(variants, len(s.regs))
+		for _, r := range s.regs {
+			if fields, _ := packed_fields(r); len(fields) > 0 {
+				ada_register_record(w, t+"_"+components[layout_key(r)], r)
+			}
+		}
+	}
+
+	line := func(indent string, r RegisterInfo) {
+		ada_comment(w, indent, r.Description)
+		fmt.Fprintf(w, "%v%v : aliased %v;\n", indent, components[layout_key(r)], component_type(r))
+	}
+
+	ada_comment(w, "   ", p.Description)
+	if variants == 1 {
+		fmt.Fprintf(w, "   type %v_Peripheral is record\n", t)
+		for _, s := range pl.slots {
+			line("      ", s.regs[0])
+		}
+	} else {
+		// the variant i has the ith register of each slot with more than one
+		fmt.Fprintf(w, "   type %v_Disc is\n     (", t)
+		for i := range variants {
+			if i > 0 {
+				fmt.Fprint(w, ",\n      ")
+			}
+			fmt.Fprintf(w, "Mode_%v", i+1)
+		}
+		fmt.Fprintln(w, ");")
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "   type %v_Peripheral\n     (Discriminant : %v_Disc := Mode_1)\n   is record\n", t, t)
+		for _, s := range pl.slots {
+			if len(s.regs) == 1 {
+				line("      ", s.regs[0])
+			}
+		}
+		fmt.Fprintln(w, "      case Discriminant is")
+		for i := range variants {
+			fmt.Fprintf(w, "         when Mode_%v =>\n", i+1)
+			empty := true
+			for _, s := range pl.slots {
+				if len(s.regs) > 1 && i < len(s.regs) {
+					line("            ", s.regs[i])
+					empty = false
+				}
+			}
+			if empty {
+				fmt.Fprintln(w, "            null;")
+			}
+		}
+		fmt.Fprintln(w, "      end case;")
+	}
+	fmt.Fprintln(w, "   end record")
+	if variants == 1 {
+		fmt.Fprintln(w, "     with Volatile;")
+	} else {
+		fmt.Fprintln(w, "     with Unchecked_Union, Volatile;")
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "   for %v_Peripheral use record\n", t)
+	for _, s := range pl.slots {
+		for _, r := range s.regs {
+			fmt.Fprintf(w, "      %v at %v range 0 .. %v;\n", components[layout_key(r)], ada_number(s.offset), register_bits(r)-1)
+		}
+	}
+	fmt.Fprintln(w, "   end record;")
+	for _, r := range pl.overlapping {
+		fmt.Fprintf(w, "   --  %v at 0x%03X overlaps another register so is not included\n", pl.name(r), uint64(r.AddressOffset))
+	}
+	fmt.Fprintln(w)
+}
+
+// the widths of the unsigned types used by the fields, reserved gaps and registers without fields
+func ada_uint_widths(types []PeripheralInfo) []int {
+	used := make(map[int]bool)
+	for _, p := range types {
+		for _, r := range p.Registers {
+			if fields, _ := packed_fields(r); len(fields) == 0 {
+				used[register_bits(r)] = true
+				continue
+			}
+			for _, c := range ada_register_components("", r) {
+				used[c.hi-c.lo+1] = true
+			}
+		}
+	}
+	var widths []int
+	for n := range used {
+		widths = append(widths, n)
+	}
+	slices.Sort(widths)
+	return widths
+}
+
+func gen_ada(w io.Writer, d DeviceInfo) {
+	pkg := ada_name(d.Name)
+
+	fmt.Fprintf(w, "--  %v\n", generated_by(d.Name))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "pragma Restrictions (No_Elaboration_Code);")
+	fmt.Fprintln(w, "pragma Ada_2012;")
+	fmt.Fprintln(w, "pragma Style_Checks (Off);")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "with System;")
+	fmt.Fprintln(w)
+	ada_comment(w, "", d.Description)
+	fmt.Fprintf(w, "package %v is\n", pkg)
+	fmt.Fprintln(w, "   pragma Preelaborate;")
+	fmt.Fprintln(w)
+
+	var types []PeripheralInfo
+	for _, p := range device_types(d) {
+		if len(p.Registers) > 0 {
+			types = append(types, p)
+		}
+	}
+
+	ada_banner(w, "Types")
+	for _, n := range ada_uint_widths(types) {
+		fmt.Fprintf(w, "   type %v is mod 2**%v\n     with Size => %v;\n", ada_uint(n), n, n)
+	}
+	fmt.Fprintln(w)
+
+	for _, p := range types {
+		ada_peripheral_type(w, p)
+	}
+
+	// peripherals without registers just have the base address
+	ada_banner(w, "Peripherals")
+	for _, p := range d.Peripherals {
+		name := ada_name(p.Name)
+		ada_comment(w, "   ", p.Description)
+		fmt.Fprintf(w, "   %v_Base : constant System.Address := System'To_Address (%v);\n", name, ada_number(uint64(p.BaseAddress)))
+		if len(p.Registers) > 0 {
+			fmt.Fprintf(w, "   %v_Periph : aliased %v_Peripheral\n     with Import, Address => %v_Base;\n", name, ada_name(type_name(p)), name)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "end %v;\n", pkg)
+}
+
+// generate an ada package spec for the peripherals matching any of the patterns, or all of them
+func GenAda(periph_pats []string) error {
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	gen_ada(os.Stdout, d)
+	return nil
+}
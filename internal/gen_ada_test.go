@@ -0,0 +1,107 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var ada_object_size = regexp.MustCompile(`Object_Size => ([0-9]+)`)
+var ada_rep_clause = regexp.MustCompile(`^   for (\S+) use record$`)
+var ada_range = regexp.MustCompile(`^      (\S+) at 0 range ([0-9]+) \.\. ([0-9]+);$`)
+
+func TestGenAda(t *testing.T) {
+	d, err := collect_device([]string{"UART*", "TIMER*", "WDT"})
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+
+	var b bytes.Buffer
+	gen_ada(&b, d)
+	as := b.String()
+
+	for _, want := range []string{
+		"package LPC176x5x is\n",
+		"   type UART0_LCR_Register is record\n",
+		"     with Volatile_Full_Access, Object_Size => 32,\n",
+		"      WLS at 0 range 0 .. 1;\n",
+		"   type TIMER0_MCR_MR0I_Field is\n",
+		"     (INTERRUPT_IS_DISABLE => 0,\n",
+		"      WDEN : WDT_MOD_k_WDEN_Field := STOP;\n",
+		"     (Discriminant : UART0_Disc := Mode_1)\n",
+		"     with Unchecked_Union, Volatile;\n",
+		"      RBR at 16#0# range 0 .. 31;\n",
+		"   UART2_Base : constant System.Address := System'To_Address (16#40098000#);\n",
+		"   UART2_Periph : aliased UART0_Peripheral\n     with Import, Address => UART2_Base;\n",
+		"end LPC176x5x;\n",
+	} {
+		if !strings.Contains(as, want) {
+			t.Errorf(`gen_ada() does not contain %q`, want)
+		}
+	}
+
+	if strings.Count(as, " is record\n") != strings.Count(as, "   end record\n") {
+		t.Errorf("gen_ada() records do not balance")
+	}
+
+	// there is no ada compiler to check it with so check each register record is covered by its fields exactly once
+	sizes := make(map[string]int)
+	lines := strings.Split(as, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, "   type ") && strings.HasSuffix(l, "_Register is record") {
+			name := strings.Fields(l)[1]
+			for j := i; j < len(lines); j++ {
+				if m := ada_object_size.FindStringSubmatch(lines[j]); m != nil {
+					sizes[name], _ = strconv.Atoi(m[1])
+					break
+				}
+			}
+		}
+	}
+	for i, l := range lines {
+		m := ada_rep_clause.FindStringSubmatch(l)
+		if m == nil || sizes[m[1]] == 0 {
+			continue
+		}
+		used := make([]int, sizes[m[1]])
+		for j := i + 1; lines[j] != "   end record;"; j++ {
+			r := ada_range.FindStringSubmatch(lines[j])
+			if r == nil {
+				t.Fatalf("unexpected line in %v: %q", m[1], lines[j])
+			}
+			lo, _ := strconv.Atoi(r[2])
+			hi, _ := strconv.Atoi(r[3])
+			for bit := lo; bit <= hi && bit < len(used); bit++ {
+				used[bit]++
+			}
+			if hi >= len(used) {
+				t.Errorf("%v.%v range %v .. %v is outside %v bits", m[1], r[1], lo, hi, len(used))
+			}
+		}
+		for bit, n := range used {
+			if n != 1 {
+				t.Errorf("%v bit %v is used by %v fields, want 1", m[1], bit, n)
+				break
+			}
+		}
+	}
+	if len(sizes) == 0 {
+		t.Errorf("gen_ada() has no register records")
+	}
+}
+
+func TestAdaName(t *testing.T) {
+	for _, tt := range []struct{ name, want string }{
+		{"LCR", "LCR"},
+		{"MOD", "MOD_k"},
+		{"_RESERVED__1_", "RESERVED_1"},
+		{"1BIT", "Val_1BIT"},
+		{"MR[%s]", "MR"},
+	} {
+		if got := ada_name(tt.name); got != tt.want {
+			t.Errorf(`ada_name("%v") = %v, want %v`, tt.name, got, tt.want)
+		}
+	}
+}
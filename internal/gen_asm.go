@@ -2,37 +2,58 @@ package svd_lookup
 
 import (
 	"fmt"
-	"regexp"
-	"slices"
 	"strings"
 )
 
+// the names of the field equates, b_ for single bits, m_ and o_ for the mask and offset
+func asm_field_name(rname string, f Field) string {
+    if f.num_bits == 1 {
+        return "b_" + rname + "_" + f.name
+    }
+    return "m_" + rname + "_" + f.name
+}
+
+func asm_offset_name(rname string, f Field) string {
+    return "o_" + rname + "_" + f.name
+}
+
+// the lines generated by GenAsm, browse uses these too
+func asm_base_line(pr Peripheral) string {
+    return fmt.Sprintf(".equ %v_BASE, %v", pr.name, pr.base_address)
+}
+
+func asm_reg_line(r Register) string {
+    return fmt.Sprintf(".equ _%v, %v", r.name, r.address_offset)
+}
+
+func asm_field_lines(r Register, f Field) []string {
+    bf := asm_field_name(r.name, f)
+    if f.num_bits == 1 {
+        return []string{fmt.Sprintf(".equ %v, 1<<%v", bf, f.bit_offset)}
+    }
+    mask := (IntPow(2, f.num_bits) - 1) << f.bit_offset
+    return []string{fmt.Sprintf(".equ %v, 0x%08X", bf, mask), fmt.Sprintf(".equ %v, %v", asm_offset_name(r.name, f), f.bit_offset)}
+}
+
 // generate assembly defines for the specified peripheral
-func GenAsm(periph string, reg_pat string) error {
-    // if periph ends in _n then we scan for all matching peripherals that end in a number and output them
-    // eg SPI_n will get SPI0 SPI1 SPI2 etc or TIM_ will get TIM1 TIM2 ... TIM12 etc
-    var multi bool
+func GenAsm(periph string, filt Filter) error {
+    // if periph ends in _n then all the numbered instances share the register defines, as with a group
+    // eg SPI_n will get SPI0 SPI1 SPI2 etc
     if strings.HasSuffix(periph, "_n") {
-    	periph = strings.Replace(periph, "_n", "%", 1)
-		r, _ := regexp.Compile(`.*[\d]+$`)
-		pl, err := fetch_peripherals_like(periph)
-		if err != nil {
-			return fmt.Errorf("Failed to get peripherals like %v: %w", periph, err)
-		}
-
-		if len(pl) > 0 {
-			for _, p := range pl {
-				if r.MatchString(p.name) {
-	    			fmt.Printf(".equ %v_BASE, %v\n", p.name, p.base_address)
-	    			multi = true
-				}
-			}
-		}
-
-		if !multi {
-			periph = periph[:len(periph)-1]
-		}
-	}
+        name, instances, err := fetch_numbered(periph)
+        if err != nil {
+            return err
+        }
+        if len(instances) > 0 {
+            g, err := collect_group(name, instances)
+            if err != nil {
+                return err
+            }
+            asm_group(g, filt)
+            return nil
+        }
+        periph = name
+    }
 
     // collects and populates all the registers and fields for this peripheral
     pr, err := collect_registers(periph)
@@ -40,48 +61,61 @@ func GenAsm(periph string, reg_pat string) error {
         return fmt.Errorf("Failed to collect registers for peripheral %v: %w", periph, err)
     }
 
-    if !multi {
-    	fmt.Printf(".equ %v_BASE, %v\n", pr.name, pr.base_address)
-    }
+    fmt.Println(asm_base_line(pr))
 
     // print out
     if pr.registers != nil {
-        regs := *pr.registers
+        // filter out registers and fields if required
+        asm_registers(periph, filt.apply(*pr.registers))
+    }
 
-        if reg_pat != "" {
-            // filter out registers if required
-            regs = slices.DeleteFunc(regs, func(n Register) bool {
-                return !strings.Contains(strings.ToLower(n.name), strings.ToLower(reg_pat))
-            })
-        }
+    return nil
+}
 
-        if len(regs) == 0 {
-        	return nil
-        }
+// generate the base address of every instance in the peripheral group and one set of register and field defines they all use
+func GenAsmGroup(group string, filt Filter) error {
+    g, err := fetch_group(group)
+    if err != nil {
+        return err
+    }
+    asm_group(g, filt)
+    return nil
+}
 
-		fmt.Printf("; Registers for %v\n", periph)
-        // print out register constants
-        for _, r := range regs {
-            fmt.Printf("  .equ _%v, %v\n", r.name, r.address_offset)
-        }
+func asm_group(g periph_group, filt Filter) {
 
-        // print out the fields for each register
-        for _, r := range regs {
-            fmt.Printf("; Bitfields for _%v\n", r.name)
-            if r.fields != nil {
-                for _, f := range *r.fields {
-                    bf := r.name + "_" + f.name
-                    if f.num_bits == 1 {
-                        fmt.Printf("  .equ b_%v, 1<<%v\n", bf, f.bit_offset)
-                    } else {
-                        mask := (IntPow(2, f.num_bits) - 1) << f.bit_offset
-                        fmt.Printf("  .equ m_%v, 0x%08X\n", bf, mask)
-                        fmt.Printf("  .equ o_%v, %v\n", bf, f.bit_offset)
-                    }
+    fmt.Printf("; Peripheral group %v\n", g.name)
+    for _, p := range g.instances {
+        fmt.Println(asm_base_line(p))
+    }
+    for _, n := range g.differ {
+        fmt.Printf("; %v does not have the same registers as %v so is not covered by these\n", n, g.pr.name)
+    }
+
+    asm_registers(g.name, filt.apply(*g.pr.registers))
+}
+
+// the register offsets and bitfields, these are relative to the base so are the same for all instances
+func asm_registers(name string, regs []Register) {
+    if len(regs) == 0 {
+        return
+    }
+
+    fmt.Printf("; Registers for %v\n", name)
+    // print out register constants
+    for _, r := range regs {
+        fmt.Printf("  %v\n", asm_reg_line(r))
+    }
+
+    // print out the fields for each register
+    for _, r := range regs {
+        fmt.Printf("; Bitfields for _%v\n", r.name)
+        if r.fields != nil {
+            for _, f := range *r.fields {
+                for _, l := range asm_field_lines(r, f) {
+                    fmt.Printf("  %v\n", l)
                 }
             }
         }
     }
-
-    return nil
 }
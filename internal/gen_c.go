@@ -0,0 +1,135 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// generate a CMSIS style C header, a typedef struct for each peripheral type, base and pointer macros
+// for each peripheral and _Pos and _Msk macros for each field
+
+// the CMSIS qualifier for the register access
+func c_qualifier(access string) string {
+	switch access {
+	case "read-only":
+		return "__I "
+	case "write-only", "writeOnce":
+		return "__O "
+	default:
+		return "__IO"
+	}
+}
+
+func c_register_line(pl peripheral_layout, r RegisterInfo, indent string) string {
+	return fmt.Sprintf("%v%v uint%v_t %v; /* 0x%03X %v */", indent, c_qualifier(r.Access), register_bits(r),
+		pl.name(r), uint64(r.AddressOffset), comment_text(r.Description, 60))
+}
+
+// the padding before a slot, as words if it is aligned
+func c_reserved_line(gap uint64, offset uint64, n int) string {
+	if gap%4 == 0 && offset%4 == 0 {
+		return fmt.Sprintf("  uint32_t RESERVED%v[%v];", n, gap/4)
+	}
+	return fmt.Sprintf("  uint8_t RESERVED%v[%v];", n, gap)
+}
+
+func c_struct(w io.Writer, p PeripheralInfo) {
+	name := identifier(type_name(p))
+	pl := layout_registers(p.Registers)
+
+	fmt.Fprintf(w, "/* %v %v */\n", name, comment_text(p.Description, 100))
+	fmt.Fprintln(w, "typedef struct {")
+	nreserved := 0
+	for i, s := range pl.slots {
+		if gap := pl.gap(i); gap > 0 {
+			fmt.Fprintln(w, c_reserved_line(gap, s.offset-gap, nreserved))
+			nreserved++
+		}
+		if len(s.regs) == 1 {
+			fmt.Fprintln(w, c_register_line(pl, s.regs[0], "  "))
+			continue
+		}
+		fmt.Fprintln(w, "  union {")
+		for _, r := range s.regs {
+			fmt.Fprintln(w, c_register_line(pl, r, "    "))
+		}
+		fmt.Fprintln(w, "  };")
+	}
+	for _, r := range pl.overlapping {
+		fmt.Fprintf(w, "  /* %v at 0x%03X overlaps another register so is not included */\n", pl.name(r), uint64(r.AddressOffset))
+	}
+	fmt.Fprintf(w, "} %v_TypeDef;\n\n", name)
+}
+
+// the field macros are named after the type so are shared by derived peripherals eg SPI1_CR1_BR_Pos
+func c_field_macros(w io.Writer, p PeripheralInfo) {
+	name := identifier(type_name(p))
+	pl := layout_registers(p.Registers)
+	seen := make(map[string]bool)
+	for _, r := range by_offset(p.Registers) {
+		if len(r.Fields) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "/* %v %v */\n", name, pl.name(r))
+		for _, f := range r.Fields {
+			m := name + "_" + pl.name(r) + "_" + identifier(f.Name)
+			// eg several fields called RESERVED
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			fmt.Fprintf(w, "#define %v_Pos %vU\n", m, f.BitOffset)
+			fmt.Fprintf(w, "#define %v_Msk (0x%XUL << %v_Pos)\n", m, field_mask_value(f), m)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func gen_c(w io.Writer, d DeviceInfo) {
+	guard := strings.ToUpper(identifier(d.Name)) + "_H"
+
+	fmt.Fprintf(w, "/* %v */\n", generated_by(d.Name))
+	fmt.Fprintf(w, "#ifndef %v\n#define %v\n\n", guard, guard)
+	fmt.Fprintln(w, "#include <stdint.h>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "#ifndef __I\n#define __I volatile const\n#endif")
+	fmt.Fprintln(w, "#ifndef __O\n#define __O volatile\n#endif")
+	fmt.Fprintln(w, "#ifndef __IO\n#define __IO volatile\n#endif")
+	fmt.Fprintln(w)
+
+	// peripherals without registers just have the base address
+	types := device_types(d)
+	for _, p := range types {
+		if len(p.Registers) > 0 {
+			c_struct(w, p)
+		}
+	}
+
+	fmt.Fprintln(w, "/* Peripheral base addresses and pointers */")
+	for _, p := range d.Peripherals {
+		name := identifier(p.Name)
+		fmt.Fprintf(w, "#define %v_BASE 0x%08XUL\n", name, uint64(p.BaseAddress))
+		if len(p.Registers) > 0 {
+			fmt.Fprintf(w, "#define %v ((%v_TypeDef *) %v_BASE)\n", name, identifier(type_name(p)), name)
+		}
+	}
+	fmt.Fprintln(w)
+
+	for _, p := range types {
+		c_field_macros(w, p)
+	}
+
+	fmt.Fprintf(w, "#endif /* %v */\n", guard)
+}
+
+// generate a C header for the peripherals matching any of the patterns, or all of them
+func GenC(periph_pats []string) error {
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	gen_c(os.Stdout, d)
+	return nil
+}
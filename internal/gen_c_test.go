@@ -0,0 +1,60 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenC(t *testing.T) {
+	d, err := collect_device([]string{"UART*", "TIMER*"})
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+
+	var b bytes.Buffer
+	gen_c(&b, d)
+	h := b.String()
+
+	for _, want := range []string{
+		"} UART0_TypeDef;",
+		"    __I  uint32_t RBR; /* 0x000",
+		"  __IO uint32_t LCR; /* 0x00C",
+		"  uint32_t RESERVED0[1];",
+		"#define UART2 ((UART0_TypeDef *) UART2_BASE)",
+		"#define UART0_BASE 0x4000C000UL",
+		"#define UART0_LCR_WLS_Msk (0x3UL << UART0_LCR_WLS_Pos)",
+		"#define TIMER0_MCR_MR0I_Pos 0U",
+	} {
+		if !strings.Contains(h, want) {
+			t.Errorf(`gen_c() does not contain "%v"`, want)
+		}
+	}
+	if strings.Contains(h, "TIMER1_TypeDef") {
+		t.Errorf(`gen_c() has a type for the derived TIMER1`)
+	}
+
+	gcc, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc is not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lpc.h"), b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	check := `#include <stddef.h>
+#include "lpc.h"
+_Static_assert(offsetof(UART0_TypeDef, LCR) == 0x0C, "LCR");
+_Static_assert(offsetof(TIMER0_TypeDef, MCR) == 0x14, "MCR");
+`
+	if err := os.WriteFile(filepath.Join(dir, "check.c"), []byte(check), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(gcc, "-fsyntax-only", "-Wall", "-Wextra", "-std=c11", "-pedantic", "-Werror", filepath.Join(dir, "check.c")).CombinedOutput()
+	if err != nil {
+		t.Errorf("gcc -fsyntax-only failed: %v\n%s", err, out)
+	}
+}
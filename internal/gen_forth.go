@@ -2,7 +2,6 @@ package svd_lookup
 
 import (
 	"fmt"
-	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -40,10 +39,126 @@ var lib_registers_code string = `
     1 swap lshift 1-foldable ;
 `
 
+var read_reg_code string = `
+: read-reg ( mask pos reg -- value )
+    @ swap rshift and
+;
+`
+
 var Addwords bool
+// also generate field accessor words and enumerated value constants in the constants mode
+var Fieldwords bool
+
+// the names of the words generated here, these are also used by encode
+// register constant generated by GenForthConsts eg spi1_CR1
+func forth_const_reg_name(pname string, rname string) string {
+    return strings.ToLower(pname) + "_" + rname
+}
+
+// register word generated by GenForthRegs eg _spCR1
+func forth_freg_reg_name(pname string, rname string) string {
+    return "_" + strings.ToLower(pname)[0:2] + rname
+}
+
+// bitfield constant, b_ for single bits, m_ for a mask and position
+// prefixed with the lowercase peripheral name for GenForthConsts
+func forth_field_name(prefix string, rname string, f Field) string {
+    bf := rname + "_" + f.name
+    if prefix != "" {
+        bf = prefix + "_" + bf
+    }
+    if f.num_bits == 1 {
+        return "b_" + bf
+    }
+    return "m_" + bf
+}
+
+// the lines generated by GenForthConsts, browse uses these too
+func forth_base_line(pr Peripheral) string {
+    return fmt.Sprintf("%v constant %v_BASE", strings.Replace(pr.base_address, "0x", "$", 1), pr.name)
+}
+
+func forth_reg_line(pr Peripheral, r Register) string {
+    a := strings.Replace(r.address_offset, "0x", "$", 1)
+    return fmt.Sprintf("%v_BASE %v + constant %v", pr.name, a, forth_const_reg_name(pr.name, r.name))
+}
+
+func forth_field_line(pr Peripheral, r Register, f Field) string {
+    return forth_field_def(forth_field_name(strings.ToLower(pr.name), r.name, f), f)
+}
+
+// register offset constant for the peripheral groups, added to the base of an instance
+func forth_offset_line(name string, r Register) string {
+    a := strings.Replace(r.address_offset, "0x", "$", 1)
+    return fmt.Sprintf("%v constant %v", a, forth_const_reg_name(name, r.name))
+}
+
+// field accessor words eg SPI1_CR1_BR@ ( -- n ) and SPI1_CR1_BR! ( n -- ) and a constant for each enumerated value
+// eg SPI1_CR1_BR_DIV8, for a group the register constant is an offset so the words also take the base of the instance
+func forth_field_words(name string, r Register, offsets bool) []string {
+    var lines []string
+    if r.fields == nil {
+        return lines
+    }
+    reg := forth_const_reg_name(name, r.name)
+    get, set, addr := "( -- n )", "( n -- )", reg
+    if offsets {
+        get, set, addr = "( base -- n )", "( n base -- )", reg + " + >r"
+    }
+
+    seen := make(map[string]bool)
+    for _, f := range *r.fields {
+        // there is nothing to access in the reserved bits, and there are often several of them
+        w := name + "_" + r.name + "_" + f.name
+        if is_reserved(f) || seen[w] {
+            continue
+        }
+        seen[w] = true
+
+        mask := fmt.Sprintf("$%08X %v", IntPow(2, f.num_bits) - 1, f.bit_offset)
+        if offsets {
+            lines = append(lines, fmt.Sprintf(": %v@ %v %v %v r> read-reg ;", w, get, addr, mask))
+            lines = append(lines, fmt.Sprintf(": %v! %v %v %v r> modify-reg ;", w, set, addr, mask))
+        } else {
+            lines = append(lines, fmt.Sprintf(": %v@ %v %v %v read-reg ;", w, get, mask, addr))
+            lines = append(lines, fmt.Sprintf(": %v! %v %v %v modify-reg ;", w, set, mask, addr))
+        }
+        if f.enums != nil {
+            for _, e := range *f.enums {
+                lines = append(lines, fmt.Sprintf("%v constant %v_%v", e.value, w, e.name))
+            }
+        }
+    }
+    return lines
+}
+
+func forth_field_def(bf string, f Field) string {
+    if f.num_bits == 1 {
+        return fmt.Sprintf("1 %v lshift constant %v", f.bit_offset, bf)
+    }
+    mask := (IntPow(2, f.num_bits) - 1)
+    return fmt.Sprintf("$%08X %v 2constant %v", mask, f.bit_offset, bf)
+}
 
 // generate forth constants for the specified peripheral
-func GenForthConsts(periph string, reg_pat string) error {
+func GenForthConsts(periph string, filt Filter) error {
+    // if periph ends in _n then all the numbered instances share the register offsets, as with a group
+    if strings.HasSuffix(periph, "_n") {
+        name, instances, err := fetch_numbered(periph)
+        if err != nil {
+            return err
+        }
+        if len(instances) > 0 {
+            g, err := collect_group(name, instances)
+            if err != nil {
+                return err
+            }
+            forth_consts_group(g, filt)
+            return nil
+        }
+        periph = name
+    }
+
     // collects and populates all the registers and fields for this peripheral
     pr, err := collect_registers(periph)
     if err != nil {
@@ -52,57 +167,115 @@ func GenForthConsts(periph string, reg_pat string) error {
 
     if Addwords {
         fmt.Print(modify_reg_code)
+        if Fieldwords {
+            fmt.Print(read_reg_code)
+        }
         fmt.Println()
     }
 
-    fmt.Printf("%v constant %v_BASE\n", strings.Replace(pr.base_address, "0x", "$", 1), pr.name)
-
-    prefix := strings.ToLower(pr.name)
+    fmt.Println(forth_base_line(pr))
 
     // print out
     if pr.registers != nil {
-        regs := *pr.registers
+        // filter out registers and fields if required
+        forth_consts_registers(pr.name, filt.apply(*pr.registers), false, func(r Register) string {
+            return forth_reg_line(pr, r)
+        })
+    }
 
-        if reg_pat != "" {
-            // filter out registers if required
-            regs = slices.DeleteFunc(regs, func(n Register) bool {
-                return !strings.Contains(strings.ToLower(n.name), strings.ToLower(reg_pat))
-            })
-        }
+    return nil
+}
 
-        // print out register constants
-        for _, r := range regs {
-            a := strings.Replace(r.address_offset, "0x", "$", 1)
-            fmt.Printf("  %v_BASE %v + constant %v_%v\n", pr.name, a, prefix, r.name)
+// generate forth constants for the base of every instance in the peripheral group
+// and one set of register offset and field constants they all use eg GPIOA_BASE gpio_ODR +
+func GenForthConstsGroup(group string, filt Filter) error {
+    g, err := fetch_group(group)
+    if err != nil {
+        return err
+    }
+    forth_consts_group(g, filt)
+    return nil
+}
+
+func forth_consts_group(g periph_group, filt Filter) {
+    if Addwords {
+        fmt.Print(modify_reg_code)
+        if Fieldwords {
+            fmt.Print(read_reg_code)
         }
+        fmt.Println()
+    }
 
-        // print out the fields for each register
-        for _, r := range regs {
-            fmt.Printf("  \\ Bitfields for %v_%v\n", prefix, r.name)
-
-            // create constants for the bit fields
-            // m_ use with modify-reg ( value mask pos reg -- )
-            // ie 5 m_CR2_TSER SPI1 _spCR2 modify-reg
-            // b_ use either bic! or bis!
-            // ie b_CR1_SSI SPI2 _sCR1 bis!
-            if r.fields != nil {
-                for _, f := range *r.fields {
-                    bf := prefix + "_" + r.name + "_" + f.name
-                    if f.num_bits == 1 {
-                        fmt.Printf("  1 %v lshift constant b_%v\n", f.bit_offset, bf)
-                    } else {
-                        mask := (IntPow(2, f.num_bits) - 1)
-                        fmt.Printf("  $%08X %v 2constant m_%v\n", mask, f.bit_offset, bf)
-                    }
-                }
+    regs := filt.apply(*g.pr.registers)
+
+    fmt.Printf("\\ Peripheral group %v, the registers are offsets from the base of each instance", g.name)
+    if len(regs) > 0 {
+        fmt.Printf(" eg %v_BASE %v +", g.instances[0].name, forth_const_reg_name(g.name, regs[0].name))
+    }
+    fmt.Println()
+    for _, p := range g.instances {
+        fmt.Println(forth_base_line(p))
+    }
+    forth_group_differ(g)
+
+    forth_consts_registers(g.name, regs, true, func(r Register) string {
+        return forth_offset_line(g.name, r)
+    })
+}
+
+// comment the instances in the group not covered by the shared registers
+func forth_group_differ(g periph_group) {
+    for _, n := range g.differ {
+        fmt.Printf("\\ %v does not have the same registers as %v so is not covered by these\n", n, g.pr.name)
+    }
+}
+
+// the register and field constants, name is the peripheral or group name, offsets is true when the registers are offsets
+func forth_consts_registers(name string, regs []Register, offsets bool, reg_line func(Register) string) {
+    // print out register constants
+    for _, r := range regs {
+        fmt.Printf("  %v\n", reg_line(r))
+    }
+
+    // print out the fields for each register
+    for _, r := range regs {
+        fmt.Printf("  \\ Bitfields for %v\n", forth_const_reg_name(name, r.name))
+
+        // create constants for the bit fields
+        // m_ use with modify-reg ( value mask pos reg -- )
+        // ie 5 m_CR2_TSER SPI1 _spCR2 modify-reg
+        // b_ use either bic! or bis!
+        // ie b_CR1_SSI SPI2 _sCR1 bis!
+        if r.fields != nil {
+            for _, f := range *r.fields {
+                fmt.Printf("  %v\n", forth_field_def(forth_field_name(strings.ToLower(name), r.name, f), f))
+            }
+        }
+        if Fieldwords {
+            for _, l := range forth_field_words(name, r, offsets) {
+                fmt.Printf("  %v\n", l)
             }
         }
     }
-
-    return nil
 }
 
-func GenForthRegs(periph string, reg_pat string) error {
+func GenForthRegs(periph string, filt Filter) error {
+    // if periph ends in _n then all the numbered instances use the one registers structure, as with a group
+    if strings.HasSuffix(periph, "_n") {
+        name, instances, err := fetch_numbered(periph)
+        if err != nil {
+            return err
+        }
+        if len(instances) > 0 {
+            g, err := collect_group(name, instances)
+            if err != nil {
+                return err
+            }
+            return forth_regs_group(g, filt)
+        }
+        periph = name
+    }
+
     // collects and populates all the registers and fields for this peripheral
     pr, err := collect_registers(periph)
     if err != nil {
@@ -117,55 +290,79 @@ func GenForthRegs(periph string, reg_pat string) error {
 
     fmt.Printf("%v constant %v\n", strings.Replace(pr.base_address, "0x", "$", 1), pr.name)
 
+    var regs []Register
+    if pr.registers != nil {
+        // filter out registers and fields if required
+        regs = filt.apply(*pr.registers)
+    }
+
+    return forth_freg_registers(pr.name, regs)
+}
+
+// generate the forth base constant of every instance in the peripheral group and one registers structure they all use
+func GenForthRegsGroup(group string, filt Filter) error {
+    g, err := fetch_group(group)
+    if err != nil {
+        return err
+    }
+    return forth_regs_group(g, filt)
+}
+
+func forth_regs_group(g periph_group, filt Filter) error {
+    if Addwords {
+        fmt.Print(lib_registers_code)
+        fmt.Print(modify_reg_code)
+        fmt.Println()
+    }
+
+    fmt.Printf("\\ Peripheral group %v\n", g.name)
+    for _, p := range g.instances {
+        fmt.Printf("%v constant %v\n", strings.Replace(p.base_address, "0x", "$", 1), p.name)
+    }
+    forth_group_differ(g)
+
+    return forth_freg_registers(g.name, filt.apply(*g.pr.registers))
+}
+
+// the registers structure and bitfield constants, name is the peripheral or group name
+func forth_freg_registers(name string, regs []Register) error {
     fmt.Println("  registers")
-    prefix := strings.ToLower(pr.name)[0:2]
     addr := 0
 
-    // print out
-    if pr.registers != nil {
-        regs := *pr.registers
-        if reg_pat != "" {
-            // filter out registers if required
-            regs = slices.DeleteFunc(regs, func(n Register) bool {
-                return !strings.Contains(strings.ToLower(n.name), strings.ToLower(reg_pat))
-            })
-        }
-
-        // sort by address_offset (which is a string)
-        sort.Slice(regs, func(i, j int) bool {
-            a, _ := strconv.ParseUint(regs[i].address_offset[2:], 16, 32)
-            b, _ := strconv.ParseUint(regs[j].address_offset[2:], 16, 32)
-            return a < b
-        })
+    // sort by address_offset (which is a string)
+    sort.Slice(regs, func(i, j int) bool {
+        a, _ := strconv.ParseUint(regs[i].address_offset[2:], 16, 32)
+        b, _ := strconv.ParseUint(regs[j].address_offset[2:], 16, 32)
+        return a < b
+    })
 
-        // print out register constants
-        for _, r := range regs {
-            a, err := strconv.ParseUint(r.address_offset[2:], 16, 32)
-            if err != nil {
-                return fmt.Errorf("Unable to parse hex %v - %w", r.address_offset, err)
-            }
+    // print out register constants
+    for _, r := range regs {
+        a, err := strconv.ParseUint(r.address_offset[2:], 16, 32)
+        if err != nil {
+            return fmt.Errorf("Unable to parse hex %v - %w", r.address_offset, err)
+        }
 
-            if int(a) != addr {
-                fmt.Printf("    drop $%08X\n", a)
-                addr = int(a)
-            }
-            addr += 4
-            fmt.Printf("    reg _%v%v\n", prefix, r.name)
+        if int(a) != addr {
+            fmt.Printf("    drop $%08X\n", a)
+            addr = int(a)
         }
-        fmt.Println("  end-registers")
-
-        // print out the fields for each register
-        for _, r := range regs {
-            fmt.Printf("\n\\ Bitfields for %v\n", r.name)
-            if r.fields != nil {
-                for _, f := range *r.fields {
-                    bf := r.name + "_" + f.name
-                    if f.num_bits == 1 {
-                        fmt.Printf("  %v bit constant b_%v\n", f.bit_offset, bf)
-                    } else {
-                        mask := (IntPow(2, f.num_bits) - 1)
-                        fmt.Printf("  $%08X %v 2constant m_%v\n", mask, f.bit_offset, bf)
-                    }
+        addr += 4
+        fmt.Printf("    reg %v\n", forth_freg_reg_name(name, r.name))
+    }
+    fmt.Println("  end-registers")
+
+    // print out the fields for each register
+    for _, r := range regs {
+        fmt.Printf("\n\\ Bitfields for %v\n", r.name)
+        if r.fields != nil {
+            for _, f := range *r.fields {
+                bf := forth_field_name("", r.name, f)
+                if f.num_bits == 1 {
+                    fmt.Printf("  %v bit constant %v\n", f.bit_offset, bf)
+                } else {
+                    mask := (IntPow(2, f.num_bits) - 1)
+                    fmt.Printf("  $%08X %v 2constant %v\n", mask, f.bit_offset, bf)
                 }
             }
         }
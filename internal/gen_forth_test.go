@@ -0,0 +1,51 @@
+package svd_lookup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForthFieldWords(t *testing.T) {
+	pr, err := collect_registers("TIMER0")
+	if err != nil {
+		t.Fatalf(`collect_registers("TIMER0") = %v, want nil`, err)
+	}
+	i := slices.IndexFunc(*pr.registers, func(r Register) bool { return r.name == "MCR" })
+	if i < 0 {
+		t.Fatalf(`TIMER0 has no MCR register`)
+	}
+	mcr := (*pr.registers)[i]
+	// some SVDs number the reserved fields
+	fields := append(slices.Clone(*mcr.fields), Field{BasicInfo: BasicInfo{name: "RESERVED_22"}, num_bits: 4, bit_offset: 28})
+	mcr.fields = &fields
+
+	lines := forth_field_words("TIMER0", mcr, false)
+	for _, want := range []string{
+		": TIMER0_MCR_MR0I@ ( -- n ) $00000001 0 timer0_MCR read-reg ;",
+		": TIMER0_MCR_MR0I! ( n -- ) $00000001 0 timer0_MCR modify-reg ;",
+		"0 constant TIMER0_MCR_MR0I_INTERRUPT_IS_DISABLE",
+		": TIMER0_MCR_MR3S! ( n -- ) $00000001 11 timer0_MCR modify-reg ;",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf(`forth_field_words("TIMER0", MCR) does not contain %q`, want)
+		}
+	}
+	for _, l := range lines {
+		if strings.Contains(l, "_RESERVED") {
+			t.Errorf(`forth_field_words("TIMER0", MCR) has a word for the reserved bits %q`, l)
+		}
+	}
+
+	// for a group the register constant is an offset added to the base of the instance
+	lines = forth_field_words("TIMER", mcr, true)
+	for _, want := range []string{
+		": TIMER_MCR_MR0I@ ( base -- n ) timer_MCR + >r $00000001 0 r> read-reg ;",
+		": TIMER_MCR_MR0I! ( n base -- ) timer_MCR + >r $00000001 0 r> modify-reg ;",
+		"0 constant TIMER_MCR_MR0I_INTERRUPT_IS_DISABLE",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf(`forth_field_words("TIMER", MCR) does not contain %q`, want)
+		}
+	}
+}
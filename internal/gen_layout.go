@@ -0,0 +1,173 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// the memory layout of a peripheral's registers for the generators that emit structs, eg c, rust and zig
+// registers at the same offset share a slot, and become a union, and the gaps between slots are reserved padding
+
+type layout_slot struct {
+	offset uint64
+	// bytes, the size of the largest register in the slot
+	size uint64
+	regs []RegisterInfo
+}
+
+type peripheral_layout struct {
+	slots []layout_slot
+	// registers that start inside another slot so can not be in the struct
+	overlapping []RegisterInfo
+	// the unique identifier of each register in the struct
+	names map[string]string
+}
+
+// registers are keyed by name and offset as the SVD can have the same name at different offsets
+func layout_key(r RegisterInfo) string {
+	return fmt.Sprintf("%v@%X", r.Name, uint64(r.AddressOffset))
+}
+
+func (pl peripheral_layout) name(r RegisterInfo) string {
+	return pl.names[layout_key(r)]
+}
+
+// the size of the register in bytes, registers without a size are 32 bits
+func register_bytes(r RegisterInfo) uint64 {
+	if r.Size == 0 {
+		return 4
+	}
+	return uint64(r.Size+7) / 8
+}
+
+func layout_registers(regs []RegisterInfo) peripheral_layout {
+	pl := peripheral_layout{names: make(map[string]string)}
+	used := make(map[string]bool)
+	for _, r := range by_offset(regs) {
+		if _, ok := pl.names[layout_key(r)]; ok {
+			continue
+		}
+		// eg the SVD has both MR%s at different offsets, the later ones get the offset added
+		id := identifier(r.Name)
+		for n := 0; used[id]; n++ {
+			id = fmt.Sprintf("%v_%X", identifier(r.Name), uint64(r.AddressOffset))
+			if n > 0 {
+				id += fmt.Sprintf("_%v", n)
+			}
+		}
+		used[id] = true
+		pl.names[layout_key(r)] = id
+
+		off := uint64(r.AddressOffset)
+		n := len(pl.slots)
+		switch {
+		case n > 0 && pl.slots[n-1].offset == off:
+			pl.slots[n-1].regs = append(pl.slots[n-1].regs, r)
+			pl.slots[n-1].size = max(pl.slots[n-1].size, register_bytes(r))
+		case n > 0 && pl.slots[n-1].offset+pl.slots[n-1].size > off:
+			pl.overlapping = append(pl.overlapping, r)
+		default:
+			pl.slots = append(pl.slots, layout_slot{offset: off, size: register_bytes(r), regs: []RegisterInfo{r}})
+		}
+	}
+	return pl
+}
+
+// the gap in bytes before each slot
+func (pl peripheral_layout) gap(i int) uint64 {
+	if i == 0 {
+		return pl.slots[0].offset
+	}
+	return pl.slots[i].offset - (pl.slots[i-1].offset + pl.slots[i-1].size)
+}
+
+// the peripheral whose registers the type is generated from, derived peripherals use the type of the one they are derived from
+func type_name(p PeripheralInfo) string {
+	if p.DerivedFrom != "" {
+		return p.DerivedFrom
+	}
+	return p.Name
+}
+
+// the first peripheral of each type, the registers of derived peripherals are those of the type
+func device_types(d DeviceInfo) []PeripheralInfo {
+	var types []PeripheralInfo
+	seen := make(map[string]bool)
+	for _, p := range d.Peripherals {
+		t := type_name(p)
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, p)
+		}
+	}
+	sort.SliceStable(types, func(i, j int) bool {
+		return type_name(types[i]) < type_name(types[j])
+	})
+	return types
+}
+
+var non_identifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// the name made into an identifier, the %s of the SVD register arrays is removed eg MR[%s] is MR
+func identifier(name string) string {
+	s := strings.ReplaceAll(strings.ReplaceAll(name, "[%s]", ""), "%s", "")
+	s = non_identifier.ReplaceAllString(s, "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+// the number of bits for an unsigned type big enough for the register
+func register_bits(r RegisterInfo) int {
+	switch n := register_bytes(r); {
+	case n <= 1:
+		return 8
+	case n <= 2:
+		return 16
+	case n <= 4:
+		return 32
+	default:
+		return 64
+	}
+}
+
+// the fields of the register that fit in it and do not overlap, in bit order, for the generators that pack them
+// into a register sized type eg zig and ada
+func packed_fields(r RegisterInfo) (fields []FieldInfo, skipped []FieldInfo) {
+	sorted := append([]FieldInfo{}, r.Fields...)
+	slices.SortStableFunc(sorted, func(a, b FieldInfo) int {
+		return a.BitOffset - b.BitOffset
+	})
+
+	pos := 0
+	for _, f := range sorted {
+		if f.BitOffset < pos || f.NumBits < 1 || f.BitOffset+f.NumBits > register_bits(r) {
+			skipped = append(skipped, f)
+			continue
+		}
+		fields = append(fields, f)
+		pos = f.BitOffset + f.NumBits
+	}
+	return fields, skipped
+}
+
+func field_mask_value(f FieldInfo) uint64 {
+	return uint64(f.Mask) >> f.BitOffset
+}
+
+// a description made safe to put in a comment in the generated source
+func comment_text(s string, max_len int) string {
+	s = strings.ReplaceAll(s, "*/", "* /")
+	if r := []rune(s); max_len > 0 && len(r) > max_len {
+		s = strings.TrimSpace(string(r[:max_len])) + "..."
+	}
+	return s
+}
+
+func generated_by(mpu string) string {
+	return fmt.Sprintf("%v peripheral access generated by svd_lookup", mpu)
+}
@@ -0,0 +1,48 @@
+package svd_lookup
+
+import (
+	"testing"
+)
+
+func TestLayoutRegisters(t *testing.T) {
+	regs := []RegisterInfo{
+		{Name: "SR", AddressOffset: 8},
+		{Name: "THR", AddressOffset: 0},
+		{Name: "RBR", AddressOffset: 0},
+		{Name: "DR", AddressOffset: 0x10, Size: 16},
+		{Name: "DRH", AddressOffset: 0x11, Size: 8},
+		{Name: "MR%s", AddressOffset: 0x18},
+		{Name: "MR%s", AddressOffset: 0x40},
+		{Name: "CR[%s]", AddressOffset: 0x44},
+	}
+
+	pl := layout_registers(regs)
+	if len(pl.slots) != 6 {
+		t.Fatalf(`layout_registers() = %v slots, want 6`, len(pl.slots))
+	}
+	if len(pl.slots[0].regs) != 2 || pl.slots[0].size != 4 {
+		t.Errorf(`layout_registers() slot 0 = %v, want THR and RBR in a union`, pl.slots[0])
+	}
+	if pl.gap(1) != 4 || pl.gap(2) != 4 || pl.gap(3) != 6 || pl.gap(4) != 0x24 {
+		t.Errorf(`layout_registers() gaps = %v %v %v %v, want 4 4 6 0x24`, pl.gap(1), pl.gap(2), pl.gap(3), pl.gap(4))
+	}
+	if len(pl.overlapping) != 1 || pl.overlapping[0].Name != "DRH" {
+		t.Errorf(`layout_registers() overlapping = %v, want DRH`, pl.overlapping)
+	}
+
+	names := map[string]string{"MR%s@18": "MR", "MR%s@40": "MR_40", "CR[%s]@44": "CR", "THR@0": "THR"}
+	for k, want := range names {
+		if pl.names[k] != want {
+			t.Errorf(`layout_registers() name of %v = %v, want %v`, k, pl.names[k], want)
+		}
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := map[string]string{"CR1": "CR1", "MR[%s]": "MR", "ACR_": "ACR_", "GPIO-A": "GPIO_A", "2ND": "_2ND"}
+	for s, want := range tests {
+		if id := identifier(s); id != want {
+			t.Errorf(`identifier("%v") = %v, want %v`, s, id, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// generate a micropython module for the device, a uctypes layout for each peripheral type so the fields can be
+// accessed by name eg SPI1.CR1.BR = 3, and const addresses and masks for use with machine.mem32
+// the structs are uctypes.NATIVE so the registers are read and written as whole words, not a byte at a time
+
+func micropython_comment(w io.Writer, indent string, s string) {
+	if s = clean_description(s); s != "" {
+		fmt.Fprintf(w, "%v# %v\n", indent, s)
+	}
+}
+
+func micropython_layout_name(p PeripheralInfo) string {
+	return identifier(type_name(p)) + "_LAYOUT"
+}
+
+// the uctypes layout of the peripheral type, registers with fields are a struct of bitfields
+// registers at the same offset just have the same offset as uctypes allows that
+func micropython_layout(w io.Writer, p PeripheralInfo) {
+	pl := layout_registers(p.Registers)
+
+	fmt.Fprintln(w)
+	micropython_comment(w, "", p.Description)
+	fmt.Fprintf(w, "%v = {\n", micropython_layout_name(p))
+	for _, r := range by_offset(p.Registers) {
+		bits := register_bits(r)
+		if len(r.Fields) == 0 {
+			fmt.Fprintf(w, "    \"%v\": 0x%03X | uctypes.UINT%v,\n", pl.name(r), uint64(r.AddressOffset), bits)
+			continue
+		}
+
+		fmt.Fprintf(w, "    \"%v\": (0x%03X, {\n", pl.name(r), uint64(r.AddressOffset))
+		seen := make(map[string]bool)
+		for _, f := range r.Fields {
+			name := identifier(f.Name)
+			// eg several fields called RESERVED
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			fmt.Fprintf(w, "        \"%v\": %v,\n", name, micropython_field(f, bits))
+		}
+		fmt.Fprintln(w, "    }),")
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// the uctypes descriptor of the field, BF_LEN is 5 bits so a field the width of the register is not a bitfield
+// and there is no 64 bit bitfield so the fields of 64 bit registers are in the word they are in
+func micropython_field(f FieldInfo, bits int) string {
+	if f.BitOffset == 0 && f.NumBits >= bits {
+		return fmt.Sprintf("0 | uctypes.UINT%v", bits)
+	}
+	offset, pos := 0, f.BitOffset
+	if bits == 64 {
+		bits = 32
+		if pos >= 32 {
+			offset, pos = 4, pos-32
+		}
+		// a field across the two words is the whole register
+		if pos+f.NumBits > 32 {
+			return "0 | uctypes.UINT64"
+		}
+		if f.NumBits == 32 {
+			return fmt.Sprintf("%v | uctypes.UINT32", offset)
+		}
+	}
+	return fmt.Sprintf("%v | uctypes.BFUINT%v | %v << uctypes.BF_POS | %v << uctypes.BF_LEN", offset, bits, pos, f.NumBits)
+}
+
+// the base and register addresses of the peripheral and its struct
+func micropython_instance(w io.Writer, p PeripheralInfo) {
+	name := identifier(p.Name)
+	pl := layout_registers(p.Registers)
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%v_BASE = const(0x%08X)\n", name, uint64(p.BaseAddress))
+	for _, r := range by_offset(p.Registers) {
+		fmt.Fprintf(w, "%v_%v = const(0x%08X)\n", name, pl.name(r), uint64(r.Address))
+	}
+	if len(p.Registers) > 0 {
+		fmt.Fprintf(w, "%v = uctypes.struct(%v_BASE, %v, uctypes.NATIVE)\n", name, name, micropython_layout_name(p))
+	}
+}
+
+// the field constants are named after the type so are shared by derived peripherals eg SPI1_CR1_BR_Msk
+func micropython_field_constants(w io.Writer, p PeripheralInfo) {
+	name := identifier(type_name(p))
+	pl := layout_registers(p.Registers)
+	seen := make(map[string]bool)
+	for _, r := range by_offset(p.Registers) {
+		if len(r.Fields) == 0 {
+			continue
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "# %v %v\n", name, pl.name(r))
+		for _, f := range r.Fields {
+			c := name + "_" + pl.name(r) + "_" + identifier(f.Name)
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			fmt.Fprintf(w, "%v_Pos = const(%v)\n", c, f.BitOffset)
+			fmt.Fprintf(w, "%v_Msk = const(0x%X)\n", c, uint64(f.Mask))
+		}
+	}
+}
+
+func gen_micropython(w io.Writer, d DeviceInfo) {
+	fmt.Fprintf(w, "# %v\n", generated_by(d.Name))
+	fmt.Fprintln(w, "# the fields are accessed through the uctypes structs eg UART0.LCR.WLS = 3")
+	fmt.Fprintln(w, "# or with the addresses and masks eg machine.mem32[UART0_LCR] |= UART0_LCR_WLS_Msk")
+	fmt.Fprintln(w, "import uctypes")
+	fmt.Fprintln(w, "from micropython import const")
+
+	types := device_types(d)
+	for _, p := range types {
+		if len(p.Registers) > 0 {
+			micropython_layout(w, p)
+		}
+	}
+
+	for _, p := range d.Peripherals {
+		micropython_instance(w, p)
+	}
+
+	for _, p := range types {
+		micropython_field_constants(w, p)
+	}
+}
+
+// generate a micropython module for the peripherals matching any of the patterns, or all of them
+func GenMicroPython(periph_pats []string) error {
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	gen_micropython(os.Stdout, d)
+	return nil
+}
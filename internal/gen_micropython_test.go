@@ -0,0 +1,111 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// a stand in for the micropython uctypes module with the same descriptor encoding, the memory is a dict of bytes
+const micropython_uctypes_stub = `
+UINT8, UINT16, UINT32, UINT64 = 0 << 27, 2 << 27, 4 << 27, 6 << 27
+BFUINT8, BFUINT16, BFUINT32 = 8 << 27, 10 << 27, 12 << 27
+BF_POS, BF_LEN = 17, 22
+NATIVE = 2
+SIZES = {0: 1, 2: 2, 4: 4, 6: 8, 8: 1, 10: 2, 12: 4}
+memory = {}
+
+def read(addr, n):
+    return sum(memory.get(addr + i, 0) << (8 * i) for i in range(n))
+
+def write(addr, n, v):
+    for i in range(n):
+        memory[addr + i] = (v >> (8 * i)) & 0xFF
+
+class struct:
+    def __init__(self, addr, layout, kind):
+        assert kind == NATIVE
+        object.__setattr__(self, "_addr", addr)
+        object.__setattr__(self, "_layout", layout)
+
+    def _field(self, name):
+        d = self._layout[name]
+        if isinstance(d, tuple):
+            return struct(self._addr + d[0], d[1], NATIVE), None
+        typ = d >> 27
+        pos, length = (d >> BF_POS) & 0x1F, (d >> BF_LEN) & 0x1F
+        return (self._addr + (d & 0x1FFFF), SIZES[typ], pos, length if typ >= 8 else 8 * SIZES[typ]), True
+
+    def __getattr__(self, name):
+        f, bits = self._field(name)
+        if bits is None:
+            return f
+        addr, n, pos, length = f
+        return (read(addr, n) >> pos) & ((1 << length) - 1)
+
+    def __setattr__(self, name, v):
+        (addr, n, pos, length), _ = self._field(name)
+        mask = ((1 << length) - 1) << pos
+        write(addr, n, (read(addr, n) & ~mask) | ((v << pos) & mask))
+`
+
+func TestGenMicroPython(t *testing.T) {
+	d, err := collect_device([]string{"UART*", "TIMER*"})
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+
+	var b bytes.Buffer
+	gen_micropython(&b, d)
+	ps := b.String()
+
+	for _, want := range []string{
+		"import uctypes\nfrom micropython import const\n",
+		"UART0_LAYOUT = {\n",
+		"    \"LCR\": (0x00C, {\n",
+		"        \"WLS\": 0 | uctypes.BFUINT32 | 0 << uctypes.BF_POS | 2 << uctypes.BF_LEN,\n",
+		"        \"MATCH\": 0 | uctypes.UINT32,\n",
+		"UART2_LCR = const(0x4009800C)\n",
+		"UART2 = uctypes.struct(UART2_BASE, UART0_LAYOUT, uctypes.NATIVE)\n",
+		"UART0_LCR_WLS_Pos = const(0)\nUART0_LCR_WLS_Msk = const(0x3)\n",
+	} {
+		if !strings.Contains(ps, want) {
+			t.Errorf(`gen_micropython() does not contain %q`, want)
+		}
+	}
+
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"lpc.py":         ps,
+		"uctypes.py":     micropython_uctypes_stub,
+		"micropython.py": "def const(v):\n    return v\n",
+		"check.py": `import uctypes, lpc
+lpc.UART2.LCR.WLS = 3
+lpc.UART2.LCR.DLAB = 1
+assert uctypes.read(lpc.UART2_LCR, 4) == 0x83, hex(uctypes.read(lpc.UART2_LCR, 4))
+assert lpc.UART2.LCR.WLS == 3 and lpc.UART0.LCR.WLS == 0
+lpc.TIMER1.MR.MATCH = 0x12345678
+assert uctypes.read(lpc.TIMER1_MR, 4) == 0x12345678
+assert lpc.UART0_LCR_DLAB_Msk == 1 << lpc.UART0_LCR_DLAB_Pos
+`,
+	}
+	for fn, s := range files {
+		if err := os.WriteFile(filepath.Join(dir, fn), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(python, "check.py")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("python3 check.py failed: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,125 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// run an external generator like a protoc plugin, the plugin is given a PluginRequest as json on stdin and writes
+// a PluginResponse to stdout listing the files to write, anything it writes to stderr is passed through.
+// The version is only changed if the request or response change in a way that would break a plugin.
+
+const PluginVersion = 1
+
+type PluginRequest struct {
+	Version   int        `json:"version"`
+	Generator string     `json:"generator"`
+	Parameter string     `json:"parameter,omitempty"`
+	Device    DeviceInfo `json:"device"`
+}
+
+type PluginFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+type PluginResponse struct {
+	Version int          `json:"version,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Files   []PluginFile `json:"files"`
+}
+
+// the plugin is looked up on the PATH unless it is a path to the executable
+func run_plugin(name string, req PluginRequest) (PluginResponse, error) {
+	var resp PluginResponse
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return resp, fmt.Errorf("Unable to find plugin %v - %w", name, err)
+	}
+
+	in, err := json.Marshal(req)
+	if err != nil {
+		return resp, fmt.Errorf("Failed to encode the plugin request: %w", err)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return resp, fmt.Errorf("Plugin %v failed - %w", name, err)
+	}
+
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return resp, fmt.Errorf("Unable to parse the response from plugin %v - %w", name, err)
+	}
+	if resp.Version != 0 && resp.Version != PluginVersion {
+		return resp, fmt.Errorf("plugin %v responded with version %v, this is version %v", name, resp.Version, PluginVersion)
+	}
+	if resp.Error != "" {
+		return resp, fmt.Errorf("plugin %v: %v", name, resp.Error)
+	}
+	return resp, nil
+}
+
+// the files are written under dir, a plugin can not write anywhere else
+func write_plugin_files(dir string, files []PluginFile) error {
+	// check them all first so nothing is written if any are bad
+	for _, f := range files {
+		if f.Name == "" || !filepath.IsLocal(f.Name) {
+			return fmt.Errorf("plugin file name %q must be a relative path inside the output directory", f.Name)
+		}
+	}
+
+	for _, f := range files {
+		fn := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			return fmt.Errorf("Failed to create directory for %v: %w", fn, err)
+		}
+		if err := os.WriteFile(fn, []byte(f.Content), 0644); err != nil {
+			return fmt.Errorf("Failed to write %v: %w", fn, err)
+		}
+	}
+	return nil
+}
+
+func gen_plugin(d DeviceInfo, name string, param string, dir string) ([]string, error) {
+	req := PluginRequest{Version: PluginVersion, Generator: "svd_lookup", Parameter: param, Device: template_device(d)}
+	resp, err := run_plugin(name, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := write_plugin_files(dir, resp.Files); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range resp.Files {
+		names = append(names, f.Name)
+	}
+	return names, nil
+}
+
+// run the plugin with the peripherals matching any of the patterns, or all of them, and write its files under dir
+func GenPlugin(periph_pats []string, name string, param string, dir string) error {
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	names, err := gen_plugin(d, name, param, dir)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "plugin %v did not return any files\n", name)
+	}
+	for _, n := range names {
+		fmt.Println(filepath.Join(dir, filepath.FromSlash(n)))
+	}
+	return nil
+}
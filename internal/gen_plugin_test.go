@@ -0,0 +1,90 @@
+package svd_lookup
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// a plugin that saves the request it was sent and responds with the given json
+func write_test_plugin(t *testing.T, dir string, response string) string {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	fn := filepath.Join(dir, "svd-gen-test")
+	src := "#!/bin/sh\ncat > \"$(dirname \"$0\")/request.json\"\ncat <<'EOF'\n" + response + "\nEOF\n"
+	if err := os.WriteFile(fn, []byte(src), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestGenPlugin(t *testing.T) {
+	d, err := collect_device([]string{"UART0", "WDT"})
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+
+	dir := t.TempDir()
+	plugin := write_test_plugin(t, dir, `{"version": 1, "files": [{"name": "a.txt", "content": "A\n"}, {"name": "sub/b.h", "content": "B"}]}`)
+	out := filepath.Join(dir, "out")
+
+	names, err := gen_plugin(d, plugin, "opt=1", out)
+	if err != nil {
+		t.Fatalf(`gen_plugin() = %v, want nil`, err)
+	}
+	if strings.Join(names, " ") != "a.txt sub/b.h" {
+		t.Errorf(`gen_plugin() = %v, want [a.txt sub/b.h]`, names)
+	}
+	for fn, want := range map[string]string{"a.txt": "A\n", "sub/b.h": "B"} {
+		if b, err := os.ReadFile(filepath.Join(out, fn)); err != nil || string(b) != want {
+			t.Errorf(`%v = %q, %v, want %q`, fn, b, err, want)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req PluginRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatalf(`the plugin request is not valid json - %v`, err)
+	}
+	if req.Version != PluginVersion || req.Generator != "svd_lookup" || req.Parameter != "opt=1" {
+		t.Errorf(`the plugin request is %v %v %v, want %v svd_lookup opt=1`, req.Version, req.Generator, req.Parameter, PluginVersion)
+	}
+	if req.Device.Name != "LPC176x5x" || len(req.Device.Peripherals) != 2 || req.Device.Peripherals[0].Name != "UART0" {
+		t.Fatalf(`the plugin request device is %v with %v peripherals, want LPC176x5x with UART0 and WDT`, req.Device.Name, len(req.Device.Peripherals))
+	}
+	if regs := req.Device.Peripherals[0].Registers; regs[0].AddressOffset != 0 || regs[len(regs)-1].Name != "RS485DLY" {
+		t.Errorf(`the plugin request registers are not in offset order`)
+	}
+}
+
+func TestGenPluginErrors(t *testing.T) {
+	d := DeviceInfo{Name: "test", Peripherals: []PeripheralInfo{}}
+	for _, tt := range []struct{ response, want string }{
+		{`{"error": "no peripherals"}`, "no peripherals"},
+		{`{"version": 2, "files": []}`, "version 2"},
+		{`{"files": [{"name": "../escape.txt", "content": ""}]}`, "must be a relative path"},
+		{`{"files": [{"name": "/abs.txt", "content": ""}]}`, "must be a relative path"},
+		{`not json`, "Unable to parse"},
+	} {
+		dir := t.TempDir()
+		plugin := write_test_plugin(t, dir, tt.response)
+		_, err := gen_plugin(d, plugin, "", filepath.Join(dir, "out"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf(`gen_plugin() with %v = %v, want an error containing %q`, tt.response, err, tt.want)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "escape.txt")); err == nil {
+			t.Errorf("the plugin wrote outside the output directory")
+		}
+	}
+
+	if _, err := gen_plugin(d, "svd-gen-does-not-exist", "", t.TempDir()); err == nil {
+		t.Errorf(`gen_plugin() with a missing plugin = nil, want an error`)
+	}
+}
@@ -0,0 +1,351 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// generate a no_std rust module for the device, a module for each peripheral with the base address, register offset
+// and register constants and a module for each register with its field constants
+// the registers and fields are typed with their access so eg a read-only register has no write
+
+// the register and field types the peripheral modules use
+const rust_common = `pub mod common {
+    //! The register and field types used by the peripheral modules
+    use core::marker::PhantomData;
+    use core::ptr::{read_volatile, write_volatile};
+
+    /// Register and field access
+    pub struct ReadOnly;
+    pub struct WriteOnly;
+    pub struct ReadWrite;
+
+    pub trait Readable {}
+    pub trait Writable {}
+    impl Readable for ReadOnly {}
+    impl Readable for ReadWrite {}
+    impl Writable for WriteOnly {}
+    impl Writable for ReadWrite {}
+
+    /// The unsigned types of the registers
+    pub trait Bits: Copy {
+        fn to_u64(self) -> u64;
+        fn from_u64(v: u64) -> Self;
+    }
+
+    impl Bits for u8 {
+        fn to_u64(self) -> u64 {
+            self as u64
+        }
+        fn from_u64(v: u64) -> Self {
+            v as u8
+        }
+    }
+
+    impl Bits for u16 {
+        fn to_u64(self) -> u64 {
+            self as u64
+        }
+        fn from_u64(v: u64) -> Self {
+            v as u16
+        }
+    }
+
+    impl Bits for u32 {
+        fn to_u64(self) -> u64 {
+            self as u64
+        }
+        fn from_u64(v: u64) -> Self {
+            v as u32
+        }
+    }
+
+    impl Bits for u64 {
+        fn to_u64(self) -> u64 {
+            self
+        }
+        fn from_u64(v: u64) -> Self {
+            v
+        }
+    }
+
+    /// A register of type T at an address with access A
+    pub struct Reg<T, A> {
+        addr: usize,
+        _access: PhantomData<(T, A)>,
+    }
+
+    impl<T, A> Reg<T, A> {
+        pub const fn new(addr: usize) -> Self {
+            Reg {
+                addr,
+                _access: PhantomData,
+            }
+        }
+
+        pub const fn addr(&self) -> usize {
+            self.addr
+        }
+    }
+
+    impl<T: Bits, A: Readable> Reg<T, A> {
+        pub fn read(&self) -> T {
+            unsafe { read_volatile(self.addr as *const T) }
+        }
+
+        pub fn read_field<F: Readable>(&self, field: Field<F>) -> T {
+            field.get(self.read())
+        }
+    }
+
+    impl<T: Bits, A: Writable> Reg<T, A> {
+        pub fn write(&self, value: T) {
+            unsafe { write_volatile(self.addr as *mut T, value) }
+        }
+    }
+
+    impl<T: Bits> Reg<T, ReadWrite> {
+        pub fn modify<F: FnOnce(T) -> T>(&self, f: F) {
+            self.write(f(self.read()))
+        }
+
+        /// Read modify write just the field, the value is masked to the width of the field
+        pub fn write_field<F: Writable>(&self, field: Field<F>, value: T) {
+            self.modify(|v| field.set(v, value))
+        }
+    }
+
+    /// A field of width bits starting at bit pos with access A
+    pub struct Field<A> {
+        pos: u32,
+        width: u32,
+        _access: PhantomData<A>,
+    }
+
+    impl<A> Clone for Field<A> {
+        fn clone(&self) -> Self {
+            *self
+        }
+    }
+
+    impl<A> Copy for Field<A> {}
+
+    impl<A> Field<A> {
+        pub const fn new(pos: u32, width: u32) -> Self {
+            Field {
+                pos,
+                width,
+                _access: PhantomData,
+            }
+        }
+
+        pub const fn pos(&self) -> u32 {
+            self.pos
+        }
+
+        pub const fn width(&self) -> u32 {
+            self.width
+        }
+
+        /// The mask of the field in the register
+        pub const fn mask(&self) -> u64 {
+            (u64::MAX >> (64 - self.width)) << self.pos
+        }
+
+        /// The value of the field in the register value
+        pub fn get<T: Bits>(&self, reg: T) -> T {
+            T::from_u64((reg.to_u64() & self.mask()) >> self.pos)
+        }
+
+        /// The register value with the field set to value, masked to the width of the field
+        pub fn set<T: Bits>(&self, reg: T, value: T) -> T {
+            let mask = self.mask();
+            T::from_u64((reg.to_u64() & !mask) | ((value.to_u64() << self.pos) & mask))
+        }
+    }
+}
+`
+
+var rust_keywords = []string{"as", "async", "await", "break", "const", "continue", "crate", "dyn", "else", "enum", "extern",
+	"false", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref", "return",
+	"self", "static", "struct", "super", "trait", "true", "type", "unsafe", "use", "where", "while", "abstract",
+	"become", "box", "do", "final", "macro", "override", "priv", "try", "typeof", "unsized", "virtual", "yield", "common"}
+
+// module names are lower case, keywords and the common module get an _ added
+func rust_module_name(name string) string {
+	s := strings.ToLower(identifier(name))
+	if slices.Contains(rust_keywords, s) {
+		s += "_"
+	}
+	return s
+}
+
+func rust_const_name(name string) string {
+	return strings.ToUpper(identifier(name))
+}
+
+// the access marker type from the common module
+func rust_access(access string) string {
+	switch access {
+	case "read-only":
+		return "ReadOnly"
+	case "write-only", "writeOnce":
+		return "WriteOnly"
+	default:
+		return "ReadWrite"
+	}
+}
+
+// a const definition broken after the = if it is longer than rustfmt allows
+func rust_const(w io.Writer, indent string, name string, typ string, value string) {
+	line := fmt.Sprintf("%vpub const %v: %v = %v;", indent, name, typ, value)
+	if len(line) <= 100 {
+		fmt.Fprintln(w, line)
+		return
+	}
+	fmt.Fprintf(w, "%vpub const %v: %v =\n%v    %v;\n", indent, name, typ, indent, value)
+}
+
+func rust_doc(w io.Writer, indent string, prefix string, s string) {
+	s = strings.TrimSpace(prefix + " " + clean_description(s))
+	if s != "" {
+		fmt.Fprintf(w, "%v/// %v\n", indent, s)
+	}
+}
+
+// the common types used by the items
+func rust_use(w io.Writer, indent string, path string, items map[string]bool) {
+	var names []string
+	for n := range items {
+		names = append(names, n)
+	}
+	// the names differ in the same case so this is the order rustfmt uses
+	slices.Sort(names)
+	if len(names) == 1 {
+		fmt.Fprintf(w, "%vuse %v::%v;\n", indent, path, names[0])
+	} else {
+		fmt.Fprintf(w, "%vuse %v::{%v};\n", indent, path, strings.Join(names, ", "))
+	}
+}
+
+// the registers with fields and their module names, in offset order
+func rust_field_registers(p PeripheralInfo) []RegisterInfo {
+	var regs []RegisterInfo
+	for _, r := range by_offset(p.Registers) {
+		if len(r.Fields) > 0 {
+			regs = append(regs, r)
+		}
+	}
+	return regs
+}
+
+// the bits of the field for the doc comment, not [7:0] as that is a link in rustdoc
+func rust_bits(f FieldInfo) string {
+	if f.NumBits == 1 {
+		return fmt.Sprintf("Bit %v:", f.BitOffset)
+	}
+	return fmt.Sprintf("Bits %v:%v:", f.BitOffset+f.NumBits-1, f.BitOffset)
+}
+
+func rust_field_module(w io.Writer, pl peripheral_layout, r RegisterInfo) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "    /// %v fields\n", pl.name(r))
+	fmt.Fprintf(w, "    pub mod %v {\n", rust_module_name(pl.name(r)))
+
+	uses := make(map[string]bool)
+	uses["Field"] = true
+	for _, f := range r.Fields {
+		uses[rust_access(f.Access)] = true
+	}
+	rust_use(w, "        ", "super::super::common", uses)
+
+	seen := make(map[string]bool)
+	for _, f := range r.Fields {
+		name := rust_const_name(f.Name)
+		// eg several fields called RESERVED
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		fmt.Fprintln(w)
+		rust_doc(w, "        ", rust_bits(f), f.Description)
+		rust_const(w, "        ", name, "Field<"+rust_access(f.Access)+">", fmt.Sprintf("Field::new(%v, %v)", f.BitOffset, f.NumBits))
+	}
+	fmt.Fprintln(w, "    }")
+}
+
+// the peripheral module, the field modules are in the module of the first peripheral of the type and used by the others
+func rust_peripheral(w io.Writer, p PeripheralInfo, field_module string) {
+	pl := layout_registers(p.Registers)
+	regs := by_offset(p.Registers)
+
+	fmt.Fprintln(w)
+	rust_doc(w, "", "", p.Description)
+	fmt.Fprintf(w, "pub mod %v {\n", rust_module_name(p.Name))
+
+	if len(regs) > 0 {
+		uses := make(map[string]bool)
+		uses["Reg"] = true
+		for _, r := range regs {
+			uses[rust_access(r.Access)] = true
+		}
+		rust_use(w, "    ", "super::common", uses)
+		fmt.Fprintln(w)
+	}
+
+	// the local BASE and registers shadow the ones from the glob so this just adds the field modules
+	field_regs := rust_field_registers(p)
+	if field_module != "" && len(field_regs) > 0 {
+		fmt.Fprintf(w, "    pub use super::%v::*;\n\n", field_module)
+	}
+
+	fmt.Fprintf(w, "    pub const BASE: usize = 0x%08X;\n", uint64(p.BaseAddress))
+
+	for _, r := range regs {
+		name := rust_const_name(pl.name(r))
+		fmt.Fprintln(w)
+		rust_doc(w, "    ", "", r.Description)
+		rust_const(w, "    ", name+"_OFFSET", "usize", fmt.Sprintf("0x%03X", uint64(r.AddressOffset)))
+		rust_const(w, "    ", name, fmt.Sprintf("Reg<u%v, %v>", register_bits(r), rust_access(r.Access)),
+			fmt.Sprintf("Reg::new(BASE + 0x%03X)", uint64(r.AddressOffset)))
+	}
+
+	if field_module == "" {
+		for _, r := range field_regs {
+			rust_field_module(w, pl, r)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func gen_rust(w io.Writer, d DeviceInfo) {
+	fmt.Fprintf(w, "//! %v\n", generated_by(d.Name))
+	fmt.Fprintln(w, "#![allow(dead_code)]")
+	fmt.Fprintln(w)
+	fmt.Fprint(w, rust_common)
+
+	// the module with the field modules for each type
+	field_modules := make(map[string]string)
+	for _, p := range d.Peripherals {
+		t := type_name(p)
+		if m, ok := field_modules[t]; ok {
+			rust_peripheral(w, p, m)
+		} else {
+			field_modules[t] = rust_module_name(p.Name)
+			rust_peripheral(w, p, "")
+		}
+	}
+}
+
+// generate a rust module for the peripherals matching any of the patterns, or all of them
+func GenRust(periph_pats []string) error {
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	gen_rust(os.Stdout, d)
+	return nil
+}
@@ -0,0 +1,68 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenRust(t *testing.T) {
+	d, err := collect_device([]string{"UART*", "TIMER*"})
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+
+	var b bytes.Buffer
+	gen_rust(&b, d)
+	rs := b.String()
+
+	for _, want := range []string{
+		"pub mod uart0 {",
+		"    pub const BASE: usize = 0x4000C000;",
+		"    pub const LCR_OFFSET: usize = 0x00C;",
+		"    pub const LCR: Reg<u32, ReadWrite> = Reg::new(BASE + 0x00C);",
+		"    pub const RBR: Reg<u32, ReadOnly> = Reg::new(BASE + 0x000);",
+		"        pub const WLS: Field<ReadWrite> = Field::new(0, 2);",
+		"    pub use super::uart0::*;",
+		"    pub const MR: Reg<u32, ReadWrite> = Reg::new(BASE + 0x018);",
+	} {
+		if !strings.Contains(rs, want) {
+			t.Errorf(`gen_rust() does not contain "%v"`, want)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lpc.rs"), b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if rustfmt, err := exec.LookPath("rustfmt"); err == nil {
+		out, err := exec.Command(rustfmt, "--edition", "2021", "--check", filepath.Join(dir, "lpc.rs")).CombinedOutput()
+		if err != nil {
+			t.Errorf("rustfmt --check failed: %v\n%s", err, out)
+		}
+	}
+
+	rustc, err := exec.LookPath("rustc")
+	if err != nil {
+		t.Skip("rustc is not installed")
+	}
+	lib := `#![no_std]
+pub mod lpc;
+pub fn set_wls() -> u32 {
+    lpc::uart2::LCR.write_field(lpc::uart2::lcr::WLS, 3);
+    lpc::uart0::RBR.read()
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "lib.rs"), []byte(lib), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(rustc, "--edition", "2021", "--crate-type", "lib", "--emit=metadata", "-D", "warnings", "lib.rs")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("rustc failed: %v\n%s", err, out)
+	}
+}
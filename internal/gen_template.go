@@ -0,0 +1,242 @@
+package svd_lookup
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// render a go text/template against the device model, the same model the json output writes with the registers
+// of each peripheral sorted by offset. The built in asm and forth outputs are bundled as templates so they can be
+// shown with --show-template, copied and changed, they give the same definitions but in register offset order
+
+//go:embed templates/*.tmpl
+var bundled_templates embed.FS
+
+// the names of the bundled templates eg asm, forth
+func BundledTemplates() []string {
+	entries, _ := bundled_templates.ReadDir("templates")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func bundled_template(name string) ([]byte, error) {
+	b, err := bundled_templates.ReadFile(path.Join("templates", name+".tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("no template file or bundled template called %v, the bundled templates are %v", name, strings.Join(BundledTemplates(), ", "))
+	}
+	return b, nil
+}
+
+// a template file if there is one, otherwise one of the bundled templates
+func load_template(name string) (*template.Template, error) {
+	src, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		src, err = bundled_template(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.New(path.Base(name)).Funcs(template_funcs).Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse template %v - %w", name, err)
+	}
+	return t, nil
+}
+
+// the words in a name, split at underscores, non identifier characters and lower to upper case changes
+func name_words(s string) []string {
+	var words []string
+	var word []rune
+	rs := []rune(s)
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = nil
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) && (unicode.IsLower(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]))) {
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// eg UART_LCR is Uart_Lcr
+func title_case(s string) string {
+	rs := []rune(strings.ToLower(s))
+	for i, r := range rs {
+		if i == 0 || !unicode.IsLetter(rs[i-1]) && !unicode.IsDigit(rs[i-1]) {
+			rs[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(rs)
+}
+
+// eg DMA_CH_CFG is DmaChCfg
+func camel_case(s string) string {
+	var b strings.Builder
+	for _, w := range name_words(s) {
+		b.WriteString(title_case(w))
+	}
+	return b.String()
+}
+
+// eg DmaChCfg is dma_ch_cfg
+func snake_case(s string) string {
+	return strings.ToLower(strings.Join(name_words(s), "_"))
+}
+
+// the template arguments can be any of the model number types or an int
+func template_uint(v any) uint64 {
+	switch n := v.(type) {
+	case Hex:
+		return uint64(n)
+	case *Hex:
+		if n == nil {
+			return 0
+		}
+		return uint64(*n)
+	case int:
+		return uint64(n)
+	case uint64:
+		return n
+	case string:
+		u, _ := parse_number(n)
+		return u
+	}
+	return 0
+}
+
+// mask width [offset] is the mask of width bits shifted up by offset
+func template_mask(width int, offset ...int) uint64 {
+	m := uint64(1)<<width - 1
+	if width >= 64 {
+		m = ^uint64(0)
+	}
+	if len(offset) > 0 {
+		m <<= offset[0]
+	}
+	return m
+}
+
+var template_funcs = template.FuncMap{
+	// case conversion
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": title_case,
+	"camel": camel_case,
+	"snake": snake_case,
+	"ident": identifier,
+	"clean": clean_description,
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); len(r) > n {
+			return string(r[:n])
+		}
+		return s
+	},
+
+	// numbers
+	"hex": func(v any) string {
+		return fmt.Sprintf("0x%08X", template_uint(v))
+	},
+	"hexw": func(digits int, v any) string {
+		return fmt.Sprintf("0x%0*X", digits, template_uint(v))
+	},
+	"int": func(v any) int {
+		return int(template_uint(v))
+	},
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"sub": func(a, b int) int {
+		return a - b
+	},
+	"mask": template_mask,
+	"field_value": func(f FieldInfo, v any) uint64 {
+		return (template_uint(v) & uint64(f.Mask)) >> f.BitOffset
+	},
+
+	// forth
+	"forth_hex": func(v any) string {
+		return fmt.Sprintf("$%08X", template_uint(v))
+	},
+	"forth_hexw": func(digits int, v any) string {
+		return fmt.Sprintf("$%0*X", digits, template_uint(v))
+	},
+	// for a ( comment ) the ) would end the comment early
+	"forth_comment": func(s string) string {
+		return strings.ReplaceAll(clean_description(s), ")", "]")
+	},
+	// for a s" string" or ." string" there is no escape for the "
+	"forth_string": func(s string) string {
+		return strings.ReplaceAll(clean_description(s), `"`, "'")
+	},
+
+	// asm
+	"asm_name": identifier,
+	"asm_comment": func(s string) string {
+		return comment_text(clean_description(s), 0)
+	},
+	// a quoted string for .ascii or .asciz
+	"asm_string": strconv.Quote,
+}
+
+// the model given to the templates and plugins, the registers are in offset order rather than name order
+func template_device(d DeviceInfo) DeviceInfo {
+	var ps []PeripheralInfo
+	for _, p := range d.Peripherals {
+		p.Registers = by_offset(p.Registers)
+		ps = append(ps, p)
+	}
+	d.Peripherals = ps
+	return d
+}
+
+func gen_template(w io.Writer, d DeviceInfo, t *template.Template) error {
+	if err := t.Execute(w, template_device(d)); err != nil {
+		return fmt.Errorf("Failed to render template %v: %w", t.Name(), err)
+	}
+	return nil
+}
+
+// render the template file or bundled template for the peripherals matching any of the patterns, or all of them
+func GenTemplate(periph_pats []string, name string) error {
+	t, err := load_template(name)
+	if err != nil {
+		return err
+	}
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	return gen_template(os.Stdout, d, t)
+}
+
+// print the source of a bundled template so it can be copied and changed
+func ShowTemplate(name string) error {
+	b, err := bundled_template(name)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
@@ -0,0 +1,160 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// the name and value of each definition, the numbers are parsed as the template and the commands format them differently
+func template_definitions(t *testing.T, lines []string, define func(fs []string) (string, string)) map[string]uint64 {
+	defs := make(map[string]uint64)
+	for _, l := range lines {
+		fs := strings.Fields(strings.NewReplacer(",", " ", "$", "0x", "1<<", "").Replace(l))
+		if len(fs) == 0 || strings.HasPrefix(fs[0], ";") || fs[0] == `\` {
+			continue
+		}
+		name, value := define(fs)
+		v, err := parse_number(value)
+		if err != nil {
+			t.Fatalf("unable to parse %q - %v", l, err)
+		}
+		defs[name] = v
+	}
+	return defs
+}
+
+func render_bundled(t *testing.T, name string, pats []string) string {
+	tmpl, err := load_template(name)
+	if err != nil {
+		t.Fatalf(`load_template("%v") = %v, want nil`, name, err)
+	}
+	d, err := collect_device(pats)
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+	var b bytes.Buffer
+	if err := gen_template(&b, d, tmpl); err != nil {
+		t.Fatalf(`gen_template("%v") = %v, want nil`, name, err)
+	}
+	return b.String()
+}
+
+func TestGenTemplateAsm(t *testing.T) {
+	out := render_bundled(t, "asm", []string{"UART0", "WDT"})
+
+	var want []string
+	for _, name := range []string{"UART0", "WDT"} {
+		pr, err := collect_registers(name)
+		if err != nil {
+			t.Fatalf(`collect_registers("%v") = %v, want nil`, name, err)
+		}
+		want = append(want, asm_base_line(pr))
+		for _, r := range *pr.registers {
+			want = append(want, asm_reg_line(r))
+			for _, f := range *r.fields {
+				want = append(want, asm_field_lines(r, f)...)
+			}
+		}
+	}
+
+	// .equ name value
+	equ := func(fs []string) (string, string) { return fs[1], fs[2] }
+	got_defs := template_definitions(t, strings.Split(out, "\n"), equ)
+	want_defs := template_definitions(t, want, equ)
+	if len(got_defs) != len(want_defs) {
+		t.Errorf("asm template has %v equates, want %v", len(got_defs), len(want_defs))
+	}
+	for n, v := range want_defs {
+		if got_defs[n] != v {
+			t.Errorf("asm template %v = 0x%X, want 0x%X", n, got_defs[n], v)
+		}
+	}
+
+	// the registers of each peripheral are in offset order, the asm command has them in name order
+	var offsets []uint64
+	check := func() {
+		if len(offsets) > 1 && (offsets[len(offsets)-1] == 0 || !slices.IsSorted(offsets)) {
+			t.Errorf("asm template register offsets are %v, want them in order", offsets)
+		}
+		offsets = nil
+	}
+	for _, l := range strings.Split(out, "\n") {
+		if strings.HasPrefix(l, "; Registers for ") {
+			check()
+		}
+		if strings.HasPrefix(l, "  .equ _") {
+			offsets = append(offsets, got_defs[strings.Fields(strings.ReplaceAll(l, ",", " "))[1]])
+		}
+	}
+	check()
+}
+
+func TestGenTemplateForth(t *testing.T) {
+	out := render_bundled(t, "forth", []string{"UART0"})
+
+	pr, err := collect_registers("UART0")
+	if err != nil {
+		t.Fatalf(`collect_registers("UART0") = %v, want nil`, err)
+	}
+	want := []string{forth_base_line(pr)}
+	for _, r := range *pr.registers {
+		want = append(want, forth_reg_line(pr, r))
+		for _, f := range *r.fields {
+			want = append(want, forth_field_line(pr, r, f))
+		}
+	}
+
+	// the registers are BASE offset + constant name, the bits 1 offset lshift constant name, the masks mask offset 2constant name
+	word := func(fs []string) (string, string) {
+		name := fs[len(fs)-1]
+		if fs[len(fs)-3] == "+" || fs[len(fs)-3] == "lshift" {
+			return name, fs[1]
+		}
+		return name, fs[0]
+	}
+	got_defs := template_definitions(t, strings.Split(out, "\n"), word)
+	want_defs := template_definitions(t, want, word)
+	if len(got_defs) != len(want_defs) {
+		t.Errorf("forth template has %v words, want %v", len(got_defs), len(want_defs))
+	}
+	for n, v := range want_defs {
+		if got_defs[n] != v {
+			t.Errorf("forth template %v = 0x%X, want 0x%X", n, got_defs[n], v)
+		}
+	}
+
+	out = render_bundled(t, "forth-freg", []string{"TIMER0"})
+	for _, want := range []string{
+		"$40004000 constant TIMER0\n  registers\n    reg _tiIR\n",
+		"    reg _tiCTCR\n  end-registers\n",
+		"    drop $00000070\n",
+		"\n\\ Bitfields for MCR\n  0 bit constant b_MCR_MR0I\n",
+		"  $03FFFFFF 6 2constant m_IR_RESERVED\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("forth-freg template does not contain %q", want)
+		}
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	for _, tt := range []struct{ src, want string }{
+		{`{{upper "uart"}} {{lower "UART"}} {{title "UART_LCR"}}`, "UART uart Uart_Lcr"},
+		{`{{camel "DMA_CH_CFG"}} {{snake "DmaChCFG"}} {{ident "MR[%s]"}}`, "DmaChCfg dma_ch_cfg MR"},
+		{`{{hex 0x1F}} {{hexw 3 12}} {{mask 3 4}} {{mask 32}}`, "0x0000001F 0x00C 112 4294967295"},
+		{`{{forth_hex 255}} {{forth_hexw 3 12}} {{forth_comment "a (b)"}} {{forth_string "a \"b\""}}`, "$000000FF $00C a (b] a 'b'"},
+		{`{{asm_string "a\"b"}} {{asm_comment "a */ b"}} {{truncate 2 "uart"}} {{add 1 2}}`, `"a\"b" a * / b ua 3`},
+	} {
+		tmpl := template.Must(template.New("test").Funcs(template_funcs).Parse(tt.src))
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, nil); err != nil {
+			t.Fatalf("%v = %v, want nil", tt.src, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%v = %q, want %q", tt.src, b.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// generate a TinyGo device package, a struct of volatile registers for each peripheral type, a pointer variable for
+// each peripheral, _Pos and _Msk constants for each field and IRQ_ constants for the interrupts
+// the source is run through go/format so it is always gofmt clean
+
+// the volatile register type for the register, registers without a size are 32 bits
+func tinygo_register_type(bits int) string {
+	return fmt.Sprintf("volatile.Register%v", bits)
+}
+
+// the package name from the device name eg LPC176x5x is lpc176x5x
+func tinygo_package(mpu string) string {
+	return strings.ToLower(identifier(mpu))
+}
+
+func tinygo_comment(w io.Writer, indent string, s string) {
+	if s = clean_description(s); s != "" {
+		fmt.Fprintf(w, "%v// %v\n", indent, s)
+	}
+}
+
+// a comment at the end of a line, nothing if there is no description
+func tinygo_trailing(s string) string {
+	if s = clean_description(s); s != "" {
+		return " // " + s
+	}
+	return ""
+}
+
+func tinygo_type_name(p PeripheralInfo) string {
+	return identifier(type_name(p)) + "_Type"
+}
+
+// the struct for the peripheral type, go has no unions so registers at the same offset are one member named after
+// all of them, registers that are not aligned to their size would be moved by the go struct layout so are left out
+func tinygo_struct(w io.Writer, p PeripheralInfo) {
+	pl := layout_registers(p.Registers)
+
+	used := make(map[string]bool)
+	for _, n := range pl.names {
+		used[n] = true
+	}
+
+	fmt.Fprintln(w)
+	tinygo_comment(w, "", p.Description)
+	fmt.Fprintf(w, "type %v struct {\n", tinygo_type_name(p))
+	var pos uint64
+	for _, s := range pl.slots {
+		bits := register_bits(s.regs[0])
+		for _, r := range s.regs {
+			bits = max(bits, register_bits(r))
+		}
+		if s.offset%uint64(bits/8) != 0 {
+			for _, r := range s.regs {
+				fmt.Fprintf(w, "\t// %v at 0x%03X is not aligned so is not included\n", pl.name(r), uint64(r.AddressOffset))
+			}
+			continue
+		}
+		if s.offset > pos {
+			fmt.Fprintf(w, "\t_ [%v]byte\n", s.offset-pos)
+		}
+		pos = s.offset + uint64(bits/8)
+
+		if len(s.regs) == 1 {
+			r := s.regs[0]
+			fmt.Fprintf(w, "\t%v %v%v\n", pl.name(r), tinygo_register_type(bits), tinygo_trailing(fmt.Sprintf("0x%03X %v", s.offset, r.Description)))
+			continue
+		}
+
+		var names []string
+		for _, r := range s.regs {
+			names = append(names, pl.name(r))
+		}
+		name := strings.Join(names, "_")
+		if used[name] {
+			name = fmt.Sprintf("%v_%X", name, s.offset)
+		}
+		fmt.Fprintf(w, "\t%v %v // 0x%03X %v\n", name, tinygo_register_type(bits), s.offset, strings.Join(names, ", "))
+	}
+	for _, r := range pl.overlapping {
+		fmt.Fprintf(w, "\t// %v at 0x%03X overlaps another register so is not included\n", pl.name(r), uint64(r.AddressOffset))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// the field constants are named after the type so are shared by derived peripherals eg SPI1_CR1_BR_Pos
+func tinygo_field_constants(w io.Writer, p PeripheralInfo, seen map[string]bool) {
+	name := identifier(type_name(p))
+	pl := layout_registers(p.Registers)
+
+	var regs []RegisterInfo
+	for _, r := range by_offset(p.Registers) {
+		if len(r.Fields) > 0 {
+			regs = append(regs, r)
+		}
+	}
+	if len(regs) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "// Constants for %v\n", name)
+	fmt.Fprintln(w, "const (")
+	for i, r := range regs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		tinygo_comment(w, "\t", pl.name(r)+": "+r.Description)
+		for _, f := range r.Fields {
+			c := name + "_" + pl.name(r) + "_" + identifier(f.Name)
+			// eg several fields called RESERVED
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			tinygo_comment(w, "\t", f.Description)
+			fmt.Fprintf(w, "\t%v_Pos = %v\n", c, f.BitOffset)
+			fmt.Fprintf(w, "\t%v_Msk = 0x%X\n", c, uint64(f.Mask))
+		}
+	}
+	fmt.Fprintln(w, ")")
+}
+
+// the interrupts of the peripherals in number order, an interrupt can be listed by several peripherals
+func tinygo_interrupts(d DeviceInfo) []InterruptInfo {
+	var irqs []InterruptInfo
+	seen := make(map[string]bool)
+	for _, p := range d.Peripherals {
+		for _, irq := range p.Interrupts {
+			if !seen[irq.Name] {
+				seen[irq.Name] = true
+				irqs = append(irqs, irq)
+			}
+		}
+	}
+	slices.SortStableFunc(irqs, func(a, b InterruptInfo) int {
+		return a.Value - b.Value
+	})
+	return irqs
+}
+
+func gen_tinygo(w io.Writer, d DeviceInfo, pkg string) error {
+	if pkg == "" {
+		pkg = tinygo_package(d.Name)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated by svd_lookup. DO NOT EDIT.")
+	fmt.Fprintf(&b, "// %v\n\n", generated_by(d.Name))
+	tinygo_comment(&b, "", d.Description)
+	fmt.Fprintf(&b, "package %v\n\n", pkg)
+
+	types := device_types(d)
+	has_registers := slices.ContainsFunc(d.Peripherals, func(p PeripheralInfo) bool {
+		return len(p.Registers) > 0
+	})
+	if has_registers {
+		fmt.Fprintln(&b, "import (\n\t\"runtime/volatile\"\n\t\"unsafe\"\n)")
+	}
+
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "// Some information about this device.")
+	fmt.Fprintf(&b, "const (\n\tDevice = %q\n)\n", d.Name)
+
+	// databases converted before interrupts were stored do not have any
+	if irqs := tinygo_interrupts(d); len(irqs) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "// Interrupt numbers.")
+		fmt.Fprintln(&b, "const (")
+		for _, irq := range irqs {
+			fmt.Fprintf(&b, "\tIRQ_%v = %v%v\n", identifier(irq.Name), irq.Value, tinygo_trailing(irq.Description))
+		}
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "\t// Highest interrupt number on this device.")
+		fmt.Fprintf(&b, "\tIRQ_max = %v\n", irqs[len(irqs)-1].Value)
+		fmt.Fprintln(&b, ")")
+	}
+
+	// unsafe.Add rather than converting a uintptr as go vet reports that as a possible misuse of unsafe.Pointer
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "// Peripherals.")
+	fmt.Fprintln(&b, "var (")
+	for _, p := range d.Peripherals {
+		if len(p.Registers) > 0 {
+			tinygo_comment(&b, "\t", p.Description)
+			fmt.Fprintf(&b, "\t%v = (*%v)(unsafe.Add(nil, 0x%08X))\n", identifier(p.Name), tinygo_type_name(p), uint64(p.BaseAddress))
+		}
+	}
+	fmt.Fprintln(&b, ")")
+
+	// peripherals without registers just have the base address
+	var bases []PeripheralInfo
+	for _, p := range d.Peripherals {
+		if len(p.Registers) == 0 {
+			bases = append(bases, p)
+		}
+	}
+	if len(bases) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "// Base addresses of the peripherals without registers.")
+		fmt.Fprintln(&b, "const (")
+		for _, p := range bases {
+			fmt.Fprintf(&b, "\t%v_BASE = 0x%08X\n", identifier(p.Name), uint64(p.BaseAddress))
+		}
+		fmt.Fprintln(&b, ")")
+	}
+
+	for _, p := range types {
+		if len(p.Registers) > 0 {
+			tinygo_struct(&b, p)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range types {
+		tinygo_field_constants(&b, p, seen)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("Failed to format the generated go source: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// generate a TinyGo device package for the peripherals matching any of the patterns, or all of them
+func GenTinyGo(periph_pats []string, pkg string) error {
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	return gen_tinygo(os.Stdout, d, pkg)
+}
@@ -0,0 +1,78 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// a stand in for the TinyGo runtime/volatile package so go vet can check the generated source
+const tinygo_volatile_stub = `package volatile
+
+type Register8 struct{ Reg uint8 }
+type Register16 struct{ Reg uint16 }
+type Register32 struct{ Reg uint32 }
+type Register64 struct{ Reg uint64 }
+`
+
+func TestGenTinyGo(t *testing.T) {
+	d, err := collect_device([]string{"UART*", "TIMER*"})
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+
+	var b bytes.Buffer
+	if err := gen_tinygo(&b, d, ""); err != nil {
+		t.Fatalf(`gen_tinygo() = %v, want nil`, err)
+	}
+	gs := b.String()
+
+	for _, want := range []string{
+		"// Code generated by svd_lookup. DO NOT EDIT.",
+		"package lpc176x5x",
+		"\tIRQ_TIMER0 = 1\n",
+		"\tIRQ_UART3  = 8\n",
+		"\tIRQ_max = 8\n",
+		"= (*UART0_Type)(unsafe.Add(nil, 0x40098000))\n",
+		"\tDLL_RBR_THR   volatile.Register32 // 0x000 DLL, RBR, THR\n",
+		"\t_             [4]byte\n",
+		"\tUART0_LCR_WLS_Pos = 0\n",
+		"\tUART0_LCR_WLS_Msk = 0x3\n",
+		"\tTIMER0_MCR_MR0I_Msk = 0x1\n",
+	} {
+		if !strings.Contains(gs, want) {
+			t.Errorf(`gen_tinygo() does not contain %q`, want)
+		}
+	}
+
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	// a module with the generated package using the stub volatile package
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":               "module example.com/tinygo\n\ngo 1.22\n",
+		"volatile/volatile.go": tinygo_volatile_stub,
+		"device/lpc.go":        strings.Replace(gs, `"runtime/volatile"`, `"example.com/tinygo/volatile"`, 1),
+	}
+	for fn, s := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, fn)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fn), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(gobin, "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go vet failed: %v\n%s", err, out)
+	}
+}
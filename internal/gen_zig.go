@@ -0,0 +1,176 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// generate zig source for the device, each register with fields is a packed struct with the gaps as reserved fields,
+// each peripheral type is an extern struct with the registers at their offsets and each peripheral is a volatile pointer
+// to its type, derived peripherals use the type of the one they are derived from
+
+var zig_keywords = []string{"addrspace", "align", "allowzero", "and", "anyframe", "anytype", "asm", "async", "await",
+	"break", "callconv", "catch", "comptime", "const", "continue", "defer", "else", "enum", "errdefer", "error", "export",
+	"extern", "fn", "for", "if", "inline", "linksection", "noalias", "noinline", "nosuspend", "opaque", "or", "orelse",
+	"packed", "pub", "resume", "return", "struct", "suspend", "switch", "test", "threadlocal", "try", "union",
+	"unreachable", "usingnamespace", "var", "volatile", "while", "anyerror", "anyopaque", "bool", "comptime_float",
+	"comptime_int", "false", "isize", "noreturn", "null", "true", "type", "undefined", "usize", "void", "_"}
+
+var zig_primitive = regexp.MustCompile(`^([iuf][0-9]+|c_[a-z]+)$`)
+
+// the name as a zig identifier, keywords and primitive type names are quoted eg @"type"
+func zig_name(name string) string {
+	s := identifier(name)
+	if slices.Contains(zig_keywords, s) || zig_primitive.MatchString(s) {
+		return `@"` + s + `"`
+	}
+	return s
+}
+
+// the packed struct type of a register with fields
+func zig_register_type(name string) string {
+	return zig_name(name + "_Reg")
+}
+
+func zig_doc(w io.Writer, indent string, s string) {
+	if s = clean_description(s); s != "" {
+		fmt.Fprintf(w, "%v/// %v\n", indent, s)
+	}
+}
+
+// the packed struct for the register, gaps between the fields are reserved fields that default to 0
+func zig_packed_struct(w io.Writer, indent string, r RegisterInfo, name string) {
+	fields, skipped := packed_fields(r)
+	bits := register_bits(r)
+
+	zig_doc(w, indent, r.Description)
+	fmt.Fprintf(w, "%vpub const %v = packed struct(u%v) {\n", indent, zig_register_type(name), bits)
+
+	pos := 0
+	used := make(map[string]bool)
+	reserved := func(to int) {
+		if to > pos {
+			fmt.Fprintf(w, "%v    _reserved%v: u%v = 0,\n", indent, pos, to-pos)
+		}
+	}
+	for _, f := range fields {
+		reserved(f.BitOffset)
+		// eg several fields called RESERVED
+		fname := identifier(f.Name)
+		if used[fname] {
+			fname = fmt.Sprintf("%v_%v", fname, f.BitOffset)
+		}
+		used[fname] = true
+		zig_doc(w, indent+"    ", f.Description)
+		fmt.Fprintf(w, "%v    %v: u%v,\n", indent, zig_name(fname), f.NumBits)
+		pos = f.BitOffset + f.NumBits
+	}
+	reserved(bits)
+	for _, f := range skipped {
+		fmt.Fprintf(w, "%v    // %v at bit %v overlaps another field so is not included\n", indent, identifier(f.Name), f.BitOffset)
+	}
+	fmt.Fprintf(w, "%v};\n", indent)
+}
+
+// the type of the register field in the peripheral struct
+func zig_field_type(r RegisterInfo, name string) string {
+	if fields, _ := packed_fields(r); len(fields) > 0 {
+		return zig_register_type(name)
+	}
+	return fmt.Sprintf("u%v", register_bits(r))
+}
+
+// the padding before a register, as words if it is aligned
+func zig_reserved(gap uint64, offset uint64) string {
+	if gap%4 == 0 && offset%4 == 0 {
+		return fmt.Sprintf("    _reserved%X: [%v]u32,", offset, gap/4)
+	}
+	return fmt.Sprintf("    _reserved%X: [%v]u8,", offset, gap)
+}
+
+func zig_type_name(p PeripheralInfo) string {
+	return zig_name(identifier(type_name(p)) + "_Type")
+}
+
+// the extern struct for the peripheral type, the fields come first then the register types as zig requires
+func zig_peripheral_type(w io.Writer, p PeripheralInfo) {
+	pl := layout_registers(p.Registers)
+
+	zig_doc(w, "", p.Description)
+	fmt.Fprintf(w, "pub const %v = extern struct {\n", zig_type_name(p))
+	for i, s := range pl.slots {
+		if gap := pl.gap(i); gap > 0 {
+			fmt.Fprintln(w, zig_reserved(gap, s.offset-gap))
+		}
+		if len(s.regs) == 1 {
+			r := s.regs[0]
+			zig_doc(w, "    ", r.Description)
+			fmt.Fprintf(w, "    %v: %v,\n", zig_name(pl.name(r)), zig_field_type(r, pl.name(r)))
+			continue
+		}
+
+		// registers at the same offset are a union named after all of them
+		var names []string
+		for _, r := range s.regs {
+			names = append(names, pl.name(r))
+		}
+		fmt.Fprintf(w, "    %v: extern union {\n", zig_name(strings.Join(names, "_")))
+		for _, r := range s.regs {
+			zig_doc(w, "        ", r.Description)
+			fmt.Fprintf(w, "        %v: %v,\n", zig_name(pl.name(r)), zig_field_type(r, pl.name(r)))
+		}
+		fmt.Fprintln(w, "    },")
+	}
+	for _, r := range pl.overlapping {
+		fmt.Fprintf(w, "    // %v at 0x%03X overlaps another register so is not included\n", pl.name(r), uint64(r.AddressOffset))
+	}
+
+	for _, s := range pl.slots {
+		for _, r := range s.regs {
+			if fields, _ := packed_fields(r); len(fields) > 0 {
+				fmt.Fprintln(w)
+				zig_packed_struct(w, "    ", r, pl.name(r))
+			}
+		}
+	}
+	fmt.Fprintln(w, "};")
+	fmt.Fprintln(w)
+}
+
+func gen_zig(w io.Writer, d DeviceInfo) {
+	fmt.Fprintf(w, "//! %v\n", generated_by(d.Name))
+	fmt.Fprintln(w, "//! The registers are accessed through the volatile peripheral pointers, eg")
+	fmt.Fprintln(w, "//!     var lcr = UART0.LCR;")
+	fmt.Fprintln(w, "//!     lcr.WLS = 3;")
+	fmt.Fprintln(w, "//!     UART0.LCR = lcr;")
+	fmt.Fprintln(w)
+
+	// peripherals without registers just have the base address
+	for _, p := range device_types(d) {
+		if len(p.Registers) > 0 {
+			zig_peripheral_type(w, p)
+		}
+	}
+
+	for _, p := range d.Peripherals {
+		if len(p.Registers) > 0 {
+			fmt.Fprintf(w, "pub const %v: *volatile %v = @ptrFromInt(0x%08X);\n", zig_name(p.Name), zig_type_name(p), uint64(p.BaseAddress))
+		} else {
+			fmt.Fprintf(w, "pub const %v: usize = 0x%08X;\n", zig_name(identifier(p.Name)+"_BASE"), uint64(p.BaseAddress))
+		}
+	}
+}
+
+// generate zig source for the peripherals matching any of the patterns, or all of them
+func GenZig(periph_pats []string) error {
+	d, err := collect_device(periph_pats)
+	if err != nil {
+		return err
+	}
+	gen_zig(os.Stdout, d)
+	return nil
+}
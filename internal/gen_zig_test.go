@@ -0,0 +1,80 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var zig_packed = regexp.MustCompile(`packed struct\(u([0-9]+)\) \{`)
+var zig_width = regexp.MustCompile(`^\s*[A-Za-z_@"][^:]*: u([0-9]+)`)
+
+func TestGenZig(t *testing.T) {
+	d, err := collect_device([]string{"UART*", "TIMER*"})
+	if err != nil {
+		t.Fatalf(`collect_device() = %v, want nil`, err)
+	}
+
+	var b bytes.Buffer
+	gen_zig(&b, d)
+	zs := b.String()
+
+	for _, want := range []string{
+		"pub const UART0_Type = extern struct {",
+		"    DLL_RBR_THR: extern union {",
+		"        RBR: RBR_Reg,",
+		"    _reserved10: [1]u32,",
+		"    pub const LCR_Reg = packed struct(u32) {",
+		"        WLS: u2,",
+		"pub const UART0: *volatile UART0_Type = @ptrFromInt(0x4000C000);",
+		"pub const UART2: *volatile UART0_Type = @ptrFromInt(0x40098000);",
+		"pub const TIMER3: *volatile TIMER0_Type = @ptrFromInt(0x40094000);",
+	} {
+		if !strings.Contains(zs, want) {
+			t.Errorf(`gen_zig() does not contain "%v"`, want)
+		}
+	}
+
+	if strings.Count(zs, "{") != strings.Count(zs, "}") {
+		t.Errorf("gen_zig() braces do not balance")
+	}
+
+	// there is no zig compiler to check it with so check the fields of each packed struct fill its backing integer
+	lines := strings.Split(zs, "\n")
+	for i := 0; i < len(lines); i++ {
+		m := zig_packed.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		want, _ := strconv.Atoi(m[1])
+		start := lines[i]
+		got := 0
+		for i++; i < len(lines) && !strings.HasSuffix(lines[i], "};"); i++ {
+			if strings.HasPrefix(strings.TrimSpace(lines[i]), "//") {
+				continue
+			}
+			if w := zig_width.FindStringSubmatch(lines[i]); w != nil {
+				n, _ := strconv.Atoi(w[1])
+				got += n
+			}
+		}
+		if got != want {
+			t.Errorf("%v fields are %v bits, want %v", strings.TrimSpace(start), got, want)
+		}
+	}
+}
+
+func TestZigName(t *testing.T) {
+	for _, tt := range []struct{ name, want string }{
+		{"CR1", "CR1"},
+		{"type", `@"type"`},
+		{"u8", `@"u8"`},
+		{"MR[%s]", "MR"},
+	} {
+		if got := zig_name(tt.name); got != tt.want {
+			t.Errorf(`zig_name("%v") = %v, want %v`, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// peripheral groups from the SVD groupName, eg all the GPIOx or TIMx instances
+
+// the instances in a group and the peripheral with the registers they share
+type periph_group struct {
+	name string
+	instances []Peripheral
+	pr Peripheral
+	// instances that do not have the same registers as pr so are not covered by the shared block
+	differ []string
+}
+
+type GroupInfo struct {
+	Name        string   `json:"name" yaml:"name"`
+	Peripherals []string `json:"peripherals" yaml:"peripherals"`
+}
+
+// the names and offsets of the registers of the peripheral, instances with the same layout can share the register definitions
+func register_layout(p Peripheral) (string, error) {
+	id := p.id
+	if p.derived_from.Valid {
+		id = p.derived_from.V
+	}
+	regs, err := fetch_registers(id)
+	if err != nil {
+		return "", err
+	}
+
+	var s strings.Builder
+	for _, r := range regs {
+		fmt.Fprintf(&s, "%v@%v ", r.name, r.address_offset)
+	}
+	return s.String(), nil
+}
+
+// collect the instances of the group and the registers of the first one
+func collect_group(name string, instances []Peripheral) (periph_group, error) {
+	g := periph_group{name: name, instances: instances}
+
+	pr, err := collect_registers(instances[0].name)
+	if err != nil {
+		return g, fmt.Errorf("Failed to collect registers for peripheral %v: %w", instances[0].name, err)
+	}
+	g.pr = pr
+
+	layout, err := register_layout(pr)
+	if err != nil {
+		return g, err
+	}
+	for _, p := range instances[1:] {
+		l, err := register_layout(p)
+		if err != nil {
+			return g, err
+		}
+		if l != layout {
+			g.differ = append(g.differ, p.name)
+		}
+	}
+
+	return g, nil
+}
+
+// the group with the name given in the SVD groupName
+func fetch_group(group string) (periph_group, error) {
+	instances, err := fetch_peripherals_in_group(group)
+	if err != nil {
+		return periph_group{}, err
+	}
+	return collect_group(instances[0].group_name.V, instances)
+}
+
+var numbered_instance = regexp.MustCompile(`\d+$`)
+
+// the numbered instances for a name ending in _n, eg SPI_n is SPI0 SPI1 SPI2 and TIMER_n TIMER0 to TIMER3
+// the name is without the _n, there are no instances if nothing matches and then that name is used as the peripheral
+func fetch_numbered(periph string) (string, []Peripheral, error) {
+	name := strings.TrimSuffix(periph, "_n")
+	pl, err := fetch_peripherals_like(name + "%")
+	if err != nil {
+		return name, nil, fmt.Errorf("Failed to get peripherals like %v: %w", name, err)
+	}
+
+	var instances []Peripheral
+	for _, p := range pl {
+		if numbered_instance.MatchString(p.name) {
+			instances = append(instances, p)
+		}
+	}
+	return name, instances, nil
+}
+
+// all the groups and the peripherals in them, peripherals without a group are left out
+func fetch_groups() ([]GroupInfo, error) {
+	if !has_groups {
+		return nil, fmt.Errorf("The database has no peripheral groups, convert the SVD again to add them")
+	}
+
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+
+	var groups []GroupInfo
+	index := make(map[string]int)
+	for _, p := range periphs {
+		if !p.group_name.Valid {
+			continue
+		}
+		i, ok := index[p.group_name.V]
+		if !ok {
+			i = len(groups)
+			index[p.group_name.V] = i
+			groups = append(groups, GroupInfo{Name: p.group_name.V})
+		}
+		groups[i].Peripherals = append(groups[i].Peripherals, p.name)
+	}
+
+	// the peripherals are already sorted
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, nil
+}
+
+// list all the peripheral groups and their peripherals
+func ListGroups() error {
+	groups, err := fetch_groups()
+	if err != nil {
+		return err
+	}
+
+	if !text_output() {
+		var rows [][]string
+		for _, g := range groups {
+			for _, p := range g.Peripherals {
+				rows = append(rows, []string{g.Name, p})
+			}
+		}
+		return write_structured(groups, []string{"group", "peripheral"}, rows)
+	}
+
+	fmt.Println("Peripheral groups for MPU: ", getMPU())
+	for _, g := range groups {
+		fmt.Printf("%v: %v\n", g.Name, strings.Join(g.Peripherals, " "))
+	}
+
+	return nil
+}
+
+// list the peripherals in the group
+func ListGroup(group string) error {
+	periphs, err := fetch_peripherals_in_group(group)
+	if err != nil {
+		return err
+	}
+	return list_peripherals(periphs, "Peripherals in group " + periphs[0].group_name.V + " for MPU: ")
+}
@@ -0,0 +1,60 @@
+package svd_lookup
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	groups, err := fetch_groups()
+	if err != nil {
+		t.Fatalf(`fetch_groups() = %v, want nil`, err)
+	}
+	i := slices.IndexFunc(groups, func(g GroupInfo) bool { return g.Name == "TIMER0" })
+	if i < 0 || !slices.Equal(groups[i].Peripherals, []string{"TIMER0", "TIMER1", "TIMER2", "TIMER3"}) {
+		t.Errorf(`fetch_groups() TIMER0 = %v, want TIMER0 to TIMER3`, groups)
+	}
+
+	// derived peripherals are in the group of the one they are derived from
+	g, err := fetch_group("uart0")
+	if err != nil {
+		t.Fatalf(`fetch_group("uart0") = %v, want nil`, err)
+	}
+	var names []string
+	for _, p := range g.instances {
+		names = append(names, p.name)
+	}
+	if g.name != "UART0" || !slices.Equal(names, []string{"UART0", "UART2", "UART3"}) || g.pr.name != "UART0" || len(g.differ) != 0 {
+		t.Errorf(`fetch_group("uart0") = %v %v %v differ %v, want UART0 with UART0 UART2 UART3 and none differing`, g.name, names, g.pr.name, g.differ)
+	}
+
+	// instances with other registers are noted
+	timer, _ := fetch_peripheral_by_name("TIMER1")
+	uart, _ := fetch_peripheral_by_name("UART0")
+	g, err = collect_group("MIXED", []Peripheral{timer, uart})
+	if err != nil || !slices.Equal(g.differ, []string{"UART0"}) {
+		t.Errorf(`collect_group(TIMER1, UART0) = %v, %v, want UART0 to differ`, g.differ, err)
+	}
+
+	if _, err := fetch_group("NOTHERE"); err == nil {
+		t.Errorf(`fetch_group("NOTHERE") = nil, want an error`)
+	}
+	// the numbered instances for forth -p UART_n, UART1 has the modem registers so differs
+	name, instances, err := fetch_numbered("UART_n")
+	if err != nil {
+		t.Fatalf(`fetch_numbered("UART_n") = %v, want nil`, err)
+	}
+	g, err = collect_group(name, instances)
+	names = nil
+	for _, p := range g.instances {
+		names = append(names, p.name)
+	}
+	if err != nil || name != "UART" || !slices.Equal(names, []string{"UART0", "UART1", "UART2", "UART3"}) || !slices.Equal(g.differ, []string{"UART1"}) {
+		t.Errorf(`fetch_numbered("UART_n") = %v %v differ %v, %v, want UART with UART0 to UART3 and UART1 differing`, name, names, g.differ, err)
+	}
+
+	// without numbered instances the name is used as the peripheral
+	if name, instances, err := fetch_numbered("WDT_n"); err != nil || name != "WDT" || len(instances) != 0 {
+		t.Errorf(`fetch_numbered("WDT_n") = %v %v, %v, want WDT and no instances`, name, len(instances), err)
+	}
+}
@@ -0,0 +1,486 @@
+package svd_lookup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// language server for the names generated by the forth and asm generators, used in forth, asm and C sources
+// it speaks JSON-RPC with Content-Length framing over stdin/stdout and handles hover, completion and definition
+
+// a generated name and what it refers to, names like _spCR1 and b_CR1_SPE refer to more than one register
+type lsp_symbol struct {
+	name string
+	periph *PeripheralInfo
+	reg *RegisterInfo
+	field *FieldInfo
+}
+
+type lsp_server struct {
+	out io.Writer
+	includes []string
+	// the open documents split into lines
+	docs map[string][]string
+	// symbols by lowercase name, as forth is usually case insensitive
+	symbols map[string][]lsp_symbol
+	// all the names sorted case insensitively for completion
+	names []string
+	shutdown bool
+}
+
+type rpc_request struct {
+	ID *json.RawMessage `json:"id"`
+	Method string `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type rpc_error struct {
+	Code int `json:"code"`
+	Message string `json:"message"`
+}
+
+type lsp_position struct {
+	Line int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lsp_range struct {
+	Start lsp_position `json:"start"`
+	End lsp_position `json:"end"`
+}
+
+type lsp_location struct {
+	URI string `json:"uri"`
+	Range lsp_range `json:"range"`
+}
+
+type text_document_position struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lsp_position `json:"position"`
+}
+
+type completion_item struct {
+	Label string `json:"label"`
+	Kind int `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// LSP completion item kinds
+const (
+	completion_field = 5
+	completion_variable = 6
+	completion_module = 9
+)
+
+// the most completions returned at once, the client asks again as more is typed
+const max_completions = 200
+
+// add all the names the generators make for every peripheral, register and field
+func (s *lsp_server) load_symbols() error {
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+
+	for _, p := range periphs {
+		pr, err := collect_registers(p.name)
+		if err != nil {
+			return err
+		}
+		pi, err := peripheral_info_with(pr, *pr.registers)
+		if err != nil {
+			return err
+		}
+
+		// the forth and asm base constant, and the forth --freg base
+		s.add(lsp_symbol{name: pr.name + "_BASE", periph: &pi})
+		s.add(lsp_symbol{name: pr.name, periph: &pi})
+
+		// the model has the registers and fields in the same order
+		for i, r := range *pr.registers {
+			ri := &pi.Registers[i]
+			s.add(lsp_symbol{name: forth_const_reg_name(pr.name, r.name), periph: &pi, reg: ri})
+			s.add(lsp_symbol{name: forth_freg_reg_name(pr.name, r.name), periph: &pi, reg: ri})
+			s.add(lsp_symbol{name: "_" + r.name, periph: &pi, reg: ri})
+
+			for j, f := range *r.fields {
+				fi := &ri.Fields[j]
+				s.add(lsp_symbol{name: forth_field_name(strings.ToLower(pr.name), r.name, f), periph: &pi, reg: ri, field: fi})
+				s.add(lsp_symbol{name: forth_field_name("", r.name, f), periph: &pi, reg: ri, field: fi})
+				if f.num_bits > 1 {
+					s.add(lsp_symbol{name: asm_offset_name(r.name, f), periph: &pi, reg: ri, field: fi})
+				}
+			}
+		}
+	}
+
+	for _, syms := range s.symbols {
+		s.names = append(s.names, syms[0].name)
+	}
+	sort.Slice(s.names, func(i, j int) bool {
+		return strings.ToLower(s.names[i]) < strings.ToLower(s.names[j])
+	})
+
+	return nil
+}
+
+func (s *lsp_server) add(sym lsp_symbol) {
+	k := strings.ToLower(sym.name)
+	s.symbols[k] = append(s.symbols[k], sym)
+}
+
+// the qualified name of what the symbol refers to eg SPI1.CR1.SPE
+func (sym lsp_symbol) target() string {
+	t := sym.periph.Name
+	if sym.reg != nil {
+		t += "." + sym.reg.Name
+	}
+	if sym.field != nil {
+		t += "." + sym.field.Name
+	}
+	return t
+}
+
+// markdown describing the symbol
+func (sym lsp_symbol) hover() string {
+	var s strings.Builder
+	switch {
+	case sym.field != nil:
+		f := sym.field
+		fmt.Fprintf(&s, "**%v** field bits `%v` mask `0x%08X`", sym.target(), bit_range(Field{num_bits: f.NumBits, bit_offset: f.BitOffset}), uint64(f.Mask))
+		if sym.reg.ResetValue != nil {
+			fmt.Fprintf(&s, " reset `0x%X`", (uint64(*sym.reg.ResetValue) & uint64(f.Mask)) >> f.BitOffset)
+		}
+		fmt.Fprintf(&s, "\n\nregister `0x%08X`", uint64(sym.reg.Address))
+		if f.Description != "" {
+			fmt.Fprintf(&s, "\n\n%v", f.Description)
+		}
+		for _, e := range f.Enums {
+			fmt.Fprintf(&s, "\n- `%v` %v %v", e.Value, e.Name, e.Description)
+		}
+
+	case sym.reg != nil:
+		r := sym.reg
+		fmt.Fprintf(&s, "**%v** register address `0x%08X` offset `0x%X`", sym.target(), uint64(r.Address), uint64(r.AddressOffset))
+		if r.ResetValue != nil {
+			fmt.Fprintf(&s, " reset `0x%08X`", uint64(*r.ResetValue))
+		}
+		if r.Description != "" {
+			fmt.Fprintf(&s, "\n\n%v", r.Description)
+		}
+
+	default:
+		p := sym.periph
+		fmt.Fprintf(&s, "**%v** peripheral base `0x%08X`", p.Name, uint64(p.BaseAddress))
+		if p.DerivedFrom != "" {
+			fmt.Fprintf(&s, " derived from %v", p.DerivedFrom)
+		}
+		if p.Description != "" {
+			fmt.Fprintf(&s, "\n\n%v", p.Description)
+		}
+	}
+	return s.String()
+}
+
+// read one Content-Length framed message
+func read_message(in *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if k, v, found := strings.Cut(line, ":"); found && strings.EqualFold(k, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("bad Content-Length %v - %w", v, err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message has no Content-Length")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *lsp_server) write_message(v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %v\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (s *lsp_server) reply(id *json.RawMessage, result any) error {
+	return s.write_message(struct {
+		JSONRPC string `json:"jsonrpc"`
+		ID *json.RawMessage `json:"id"`
+		Result any `json:"result"`
+	}{"2.0", id, result})
+}
+
+func (s *lsp_server) reply_error(id *json.RawMessage, code int, err error) error {
+	return s.write_message(struct {
+		JSONRPC string `json:"jsonrpc"`
+		ID *json.RawMessage `json:"id"`
+		Error rpc_error `json:"error"`
+	}{"2.0", id, rpc_error{code, err.Error()}})
+}
+
+// handle one message, returns true when the client has asked to exit
+func (s *lsp_server) handle(req rpc_request) (bool, error) {
+	var result any
+	var err error
+
+	switch req.Method {
+	case "initialize":
+		result = map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": 1,
+				"hoverProvider": true,
+				"definitionProvider": true,
+				"completionProvider": map[string]any{"triggerCharacters": []string{"_"}},
+			},
+			"serverInfo": map[string]string{"name": "svd_lookup"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "exit":
+		return true, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err = json.Unmarshal(req.Params, &p); err == nil {
+			s.docs[p.TextDocument.URI] = strings.Split(p.TextDocument.Text, "\n")
+		}
+	case "textDocument/didChange":
+		// full document sync so the last change is the whole text
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err = json.Unmarshal(req.Params, &p); err == nil && len(p.ContentChanges) > 0 {
+			s.docs[p.TextDocument.URI] = strings.Split(p.ContentChanges[len(p.ContentChanges)-1].Text, "\n")
+		}
+	case "textDocument/didClose":
+		var p text_document_position
+		if err = json.Unmarshal(req.Params, &p); err == nil {
+			delete(s.docs, p.TextDocument.URI)
+		}
+	case "textDocument/hover":
+		var p text_document_position
+		if err = json.Unmarshal(req.Params, &p); err == nil {
+			result = s.hover(p)
+		}
+	case "textDocument/completion":
+		var p text_document_position
+		if err = json.Unmarshal(req.Params, &p); err == nil {
+			result = s.complete(p)
+		}
+	case "textDocument/definition":
+		var p text_document_position
+		if err = json.Unmarshal(req.Params, &p); err == nil {
+			result, err = s.definition(p)
+		}
+	default:
+		// unknown requests get an error, unknown notifications are ignored
+		if req.ID != nil {
+			return false, s.reply_error(req.ID, -32601, fmt.Errorf("method %v not supported", req.Method))
+		}
+		return false, nil
+	}
+
+	if req.ID == nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, s.reply_error(req.ID, -32602, err)
+	}
+	return false, s.reply(req.ID, result)
+}
+
+func is_name_char(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// the byte offset in the line of the utf-16 character position the client uses
+func byte_offset(line string, character int) int {
+	n := 0
+	for i, r := range line {
+		if n >= character {
+			return i
+		}
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return len(line)
+}
+
+// the name under the position, and the start and end byte offsets of it in the line
+func (s *lsp_server) name_at(p text_document_position) (string, string, int, int) {
+	lines := s.docs[p.TextDocument.URI]
+	if p.Position.Line < 0 || p.Position.Line >= len(lines) {
+		return "", "", 0, 0
+	}
+	line := lines[p.Position.Line]
+	pos := byte_offset(line, p.Position.Character)
+	start, end := pos, pos
+	for start > 0 && is_name_char(line[start-1]) {
+		start--
+	}
+	for end < len(line) && is_name_char(line[end]) {
+		end++
+	}
+	return line[start:end], line, start, end
+}
+
+func (s *lsp_server) hover(p text_document_position) any {
+	name, _, _, _ := s.name_at(p)
+	syms := s.symbols[strings.ToLower(name)]
+	if len(syms) == 0 {
+		return nil
+	}
+
+	// names like _spCR1 are the same for all the peripherals that start with sp
+	var parts []string
+	for i, sym := range syms {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("and %v more", len(syms)-i))
+			break
+		}
+		parts = append(parts, sym.hover())
+	}
+	return map[string]any{"contents": map[string]string{"kind": "markdown", "value": strings.Join(parts, "\n\n---\n\n")}}
+}
+
+// the generated names that start with the part of the name before the cursor
+func (s *lsp_server) complete(p text_document_position) any {
+	_, line, start, _ := s.name_at(p)
+	prefix := strings.ToLower(line[start:byte_offset(line, p.Position.Character)])
+
+	items := []completion_item{}
+	i := sort.Search(len(s.names), func(i int) bool {
+		return strings.ToLower(s.names[i]) >= prefix
+	})
+	for ; i < len(s.names) && len(items) < max_completions && strings.HasPrefix(strings.ToLower(s.names[i]), prefix); i++ {
+		syms := s.symbols[strings.ToLower(s.names[i])]
+		item := completion_item{Label: s.names[i], Kind: completion_module, Detail: syms[0].target()}
+		switch {
+		case syms[0].field != nil:
+			item.Kind = completion_field
+		case syms[0].reg != nil:
+			item.Kind = completion_variable
+		}
+		if len(syms) > 1 {
+			item.Detail += fmt.Sprintf(" and %v more", len(syms)-1)
+		}
+		items = append(items, item)
+	}
+
+	return map[string]any{"isIncomplete": len(items) == max_completions, "items": items}
+}
+
+// the definition of the name in the generated include files
+func (s *lsp_server) definition(p text_document_position) (any, error) {
+	name, _, _, _ := s.name_at(p)
+	if name == "" {
+		return nil, nil
+	}
+
+	// forth constant, 2constant and reg words, asm .equ and C #define
+	re := regexp.MustCompile(`(?i)(?:\b(?:2?constant|regC?)\s+|\.equ\s+|#define\s+)(` + regexp.QuoteMeta(name) + `)(?:[\s,(]|$)`)
+
+	locs := []lsp_location{}
+	for _, fn := range s.includes {
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read include file %v - %w", fn, err)
+		}
+		abs, err := filepath.Abs(fn)
+		if err != nil {
+			return nil, err
+		}
+		uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+
+		for n, line := range strings.Split(string(data), "\n") {
+			if m := re.FindStringSubmatchIndex(line); m != nil {
+				start := len(utf16.Encode([]rune(line[:m[2]])))
+				end := start + len(utf16.Encode([]rune(line[m[2]:m[3]])))
+				locs = append(locs, lsp_location{URI: uri, Range: lsp_range{lsp_position{n, start}, lsp_position{n, end}}})
+			}
+		}
+	}
+
+	return locs, nil
+}
+
+// run the language server until the client exits, includes are the generated files used for go to definition
+func LSP(in io.Reader, out io.Writer, includes []string) error {
+	s := &lsp_server{out: out, includes: slices.Clone(includes), docs: make(map[string][]string), symbols: make(map[string][]lsp_symbol)}
+	if err := s.load_symbols(); err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "svd_lookup lsp: %v names for MPU: %v\n", len(s.names), getMPU())
+	}
+
+	r := bufio.NewReader(in)
+	for {
+		body, err := read_message(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req rpc_request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply_error(nil, -32700, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		exit, err := s.handle(req)
+		if err != nil {
+			return err
+		}
+		if exit {
+			if !s.shutdown {
+				return fmt.Errorf("exit before shutdown")
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package svd_lookup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// frame the messages as a client would send them
+func lsp_messages(msgs ...string) string {
+	var s strings.Builder
+	for _, m := range msgs {
+		fmt.Fprintf(&s, "Content-Length: %v\r\n\r\n%v", len(m), m)
+	}
+	return s.String()
+}
+
+func TestLSP(t *testing.T) {
+	include := filepath.Join(t.TempDir(), "regs.fs")
+	os.WriteFile(include, []byte("$4000C000 constant UART0_BASE\n  $00000003 0 2constant m_uart0_LCR_WLS\n"), 0644)
+
+	src := `$83 m_uart0_LCR_WLS uart0_LCR modify-reg\nb_timer1_MCR_MR\n_tiMCR`
+	in := lsp_messages(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///x.fs","languageId":"forth","version":1,"text":"` + src + `"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///x.fs"},"position":{"line":0,"character":8}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///x.fs"},"position":{"line":1,"character":15}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///x.fs"},"position":{"line":0,"character":10}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///x.fs"},"position":{"line":2,"character":2}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///x.fs"},"position":{"line":0,"character":1}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	var out bytes.Buffer
+	if err := LSP(strings.NewReader(in), &out, []string{include}); err != nil {
+		t.Fatalf(`LSP() = %v, want nil`, err)
+	}
+
+	// the responses by id
+	responses := make(map[int]json.RawMessage)
+	r := bufio.NewReader(&out)
+	for {
+		body, err := read_message(r)
+		if err != nil {
+			break
+		}
+		var resp struct {
+			ID int `json:"id"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatalf(`LSP response %s is not json - %v`, body, err)
+		}
+		responses[resp.ID] = resp.Result
+	}
+	if len(responses) != 7 {
+		t.Fatalf(`LSP gave %v responses, want 7`, len(responses))
+	}
+
+	var hover struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	json.Unmarshal(responses[2], &hover)
+	for _, want := range []string{"UART0.LCR.WLS", "`[1:0]`", "8_BIT_CHARACTER_LENG"} {
+		if !strings.Contains(hover.Contents.Value, want) {
+			t.Errorf(`hover on m_uart0_LCR_WLS = %q, want it to contain %q`, hover.Contents.Value, want)
+		}
+	}
+
+	var completions struct {
+		Items []completion_item `json:"items"`
+	}
+	json.Unmarshal(responses[3], &completions)
+	var labels []string
+	for _, c := range completions.Items {
+		labels = append(labels, c.Label)
+	}
+	if len(labels) != 12 || labels[0] != "b_timer1_MCR_MR0I" || labels[11] != "b_timer1_MCR_MR3S" {
+		t.Errorf(`completion of b_timer1_MCR_MR = %v, want the 12 MRn fields`, labels)
+	}
+
+	var locs []lsp_location
+	json.Unmarshal(responses[4], &locs)
+	if len(locs) != 1 || !strings.HasSuffix(locs[0].URI, "/regs.fs") || locs[0].Range.Start != (lsp_position{1, 24}) || locs[0].Range.End != (lsp_position{1, 39}) {
+		t.Errorf(`definition of m_uart0_LCR_WLS = %+v, want regs.fs line 1 24-39`, locs)
+	}
+
+	// _tiMCR is the freg name for the MCR of all the timers
+	json.Unmarshal(responses[5], &hover)
+	if !strings.Contains(hover.Contents.Value, "TIMER0.MCR") || !strings.Contains(hover.Contents.Value, "TIMER3.MCR") {
+		t.Errorf(`hover on _tiMCR = %q, want all the timer MCRs`, hover.Contents.Value)
+	}
+
+	if string(responses[6]) != "null" {
+		t.Errorf(`hover on $83 = %s, want null`, responses[6])
+	}
+}
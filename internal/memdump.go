@@ -0,0 +1,159 @@
+package svd_lookup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// reading memory dumps and mapping the addresses onto the registers in the database
+
+// one 32 bit word read from memory
+type mem_word struct {
+	addr uint64
+	value uint64
+}
+
+// where a register lives in the memory map
+type reg_location struct {
+	periph string
+	base uint64
+	reg Register
+}
+
+// the address range covered by the registers of a peripheral
+type periph_span struct {
+	name string
+	base uint64
+	end uint64
+}
+
+type address_map struct {
+	regs map[uint64][]reg_location
+	spans []periph_span
+}
+
+// matches the address at the start of OpenOCD mdw lines and GDB x/wx lines
+// eg 0x40013000: 0000034c 00000000 or 0x40013000 <SPI1>:	0x0000034c	0x00000000
+var dump_line_re = regexp.MustCompile(`^\s*(0x[0-9a-fA-F]+)\s*(?:<[^>]*>)?\s*:\s*(.*)$`)
+
+// load a memory dump, if base is given the file is raw little endian binary starting at base
+// otherwise it is the text output of OpenOCD mdw or GDB x/NNwx
+func load_dump(filename string, base string) ([]mem_word, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read dump file %v - %w", filename, err)
+	}
+
+	if base != "" {
+		b, err := parse_number(base)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse base address %v - %w", base, err)
+		}
+		return parse_raw_dump(data, b), nil
+	}
+
+	words, err := parse_text_dump(data)
+	if err != nil {
+		return nil, fmt.Errorf("in dump file %v: %w", filename, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("No memory words found in %v, if it is a raw binary file use --base", filename)
+	}
+	return words, nil
+}
+
+func parse_raw_dump(data []byte, base uint64) []mem_word {
+	var words []mem_word
+	for i := 0; i+4 <= len(data); i += 4 {
+		words = append(words, mem_word{addr: base + uint64(i), value: uint64(binary.LittleEndian.Uint32(data[i:]))})
+	}
+	return words
+}
+
+func parse_text_dump(data []byte) ([]mem_word, error) {
+	var words []mem_word
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		m := dump_line_re.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			// prompts, commands etc
+			continue
+		}
+		addr, err := parse_number(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad address %v - %w", m[1], err)
+		}
+		for _, tok := range strings.Fields(m[2]) {
+			if !strings.HasPrefix(tok, "0x") {
+				tok = "0x" + tok
+			}
+			v, err := parse_number(tok)
+			if err != nil {
+				// anything after the hex words is ignored
+				break
+			}
+			words = append(words, mem_word{addr: addr, value: v})
+			addr += 4
+		}
+	}
+
+	return words, scanner.Err()
+}
+
+// build the map of every register address in the database
+func build_address_map() (address_map, error) {
+	am := address_map{regs: make(map[uint64][]reg_location)}
+
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return am, fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+
+	for _, p := range periphs {
+		base, err := parse_number(p.base_address)
+		if err != nil {
+			return am, fmt.Errorf("Unable to parse base address %v of %v - %w", p.base_address, p.name, err)
+		}
+
+		pr, err := collect_registers(p.name)
+		if err != nil {
+			return am, err
+		}
+
+		span := periph_span{name: p.name, base: base, end: base}
+		if pr.registers != nil {
+			for _, r := range *pr.registers {
+				off, err := parse_number(r.address_offset)
+				if err != nil {
+					return am, fmt.Errorf("Unable to parse offset %v of %v.%v - %w", r.address_offset, p.name, r.name, err)
+				}
+				addr := base + off
+				am.regs[addr] = append(am.regs[addr], reg_location{periph: p.name, base: base, reg: r})
+				span.end = max(span.end, addr+4)
+			}
+		}
+		am.spans = append(am.spans, span)
+	}
+
+	sort.Slice(am.spans, func(i, j int) bool {
+		return am.spans[i].base < am.spans[j].base
+	})
+
+	return am, nil
+}
+
+// the peripheral whose registers cover the address, or "" if there is none
+func (am address_map) periph_at(addr uint64) string {
+	for _, s := range am.spans {
+		if addr >= s.base && addr < s.end {
+			return s.name
+		}
+	}
+	return ""
+}
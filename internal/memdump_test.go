@@ -0,0 +1,57 @@
+package svd_lookup
+
+import (
+	"testing"
+)
+
+func TestParseTextDump(t *testing.T) {
+	dump := `> mdw 0x4000C000 3
+0x4000c000: 00000041 00000003 000000c1
+(gdb) x/2wx 0x40000000
+0x40000000 <WDT>:	0x00000003	0x000000ff
+`
+	want := []mem_word{{0x4000C000, 0x41}, {0x4000C004, 3}, {0x4000C008, 0xC1}, {0x40000000, 3}, {0x40000004, 0xFF}}
+
+	words, err := parse_text_dump([]byte(dump))
+	if err != nil || len(words) != len(want) {
+		t.Fatalf(`parse_text_dump() = %v, %v, want %v, nil`, words, err, want)
+	}
+	for i, w := range words {
+		if w != want[i] {
+			t.Errorf(`parse_text_dump() word %v = %v, want %v`, i, w, want[i])
+		}
+	}
+}
+
+func TestParseRawDump(t *testing.T) {
+	words := parse_raw_dump([]byte{0x4C, 0x03, 0, 0, 7, 0, 0, 0, 1}, 0x40004000)
+	want := []mem_word{{0x40004000, 0x34C}, {0x40004004, 7}}
+	if len(words) != len(want) || words[0] != want[0] || words[1] != want[1] {
+		t.Errorf(`parse_raw_dump() = %v, want %v`, words, want)
+	}
+}
+
+func TestAddressMap(t *testing.T) {
+	am, err := build_address_map()
+	if err != nil {
+		t.Fatalf(`build_address_map() = %v, want nil`, err)
+	}
+
+	locs := am.regs[0x4000C00C]
+	if len(locs) != 1 || locs[0].periph != "UART0" || locs[0].reg.name != "LCR" {
+		t.Errorf(`address 0x4000C00C = %v, want UART0 LCR`, locs)
+	}
+
+	// derived peripherals have the registers of the one they are derived from
+	locs = am.regs[0x40008014]
+	if len(locs) != 1 || locs[0].periph != "TIMER1" || locs[0].reg.name != "MCR" {
+		t.Errorf(`address 0x40008014 = %v, want TIMER1 MCR`, locs)
+	}
+
+	if p := am.periph_at(0x4000C010); p != "UART0" {
+		t.Errorf(`periph_at(0x4000C010) = %v, want UART0`, p)
+	}
+	if p := am.periph_at(0x20000000); p != "" {
+		t.Errorf(`periph_at(0x20000000) = %v, want ""`, p)
+	}
+}
@@ -0,0 +1,167 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// one entry in the memory map, either a peripheral or an unused gap between them
+type memmap_entry struct {
+	Kind string `json:"kind" yaml:"kind"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	Base Hex `json:"base" yaml:"base"`
+	Size Hex `json:"size" yaml:"size"`
+	End Hex `json:"end" yaml:"end"`
+	Estimated bool `json:"estimated,omitempty" yaml:"estimated,omitempty"`
+	DerivedFrom string `json:"derived_from,omitempty" yaml:"derived_from,omitempty"`
+	Overlaps []string `json:"overlaps,omitempty" yaml:"overlaps,omitempty"`
+}
+
+// the size of the address block of the peripheral, if the database does not have it
+// then it is estimated from the end of the last register
+func periph_size(p Peripheral) (uint64, bool, error) {
+	if p.block_size.Valid {
+		off, err := parse_number(p.block_offset.V)
+		if err != nil {
+			return 0, false, fmt.Errorf("Unable to parse block offset %v of %v - %w", p.block_offset.V, p.name, err)
+		}
+		size, err := parse_number(p.block_size.V)
+		if err != nil {
+			return 0, false, fmt.Errorf("Unable to parse block size %v of %v - %w", p.block_size.V, p.name, err)
+		}
+		return off + size, false, nil
+	}
+
+	id := p.id
+	if p.derived_from.Valid {
+		id = p.derived_from.V
+	}
+	regs, err := fetch_registers(id)
+	if err != nil {
+		return 0, true, err
+	}
+
+	var size uint64
+	for _, r := range regs {
+		off, err := parse_number(r.address_offset)
+		if err != nil {
+			return 0, true, fmt.Errorf("Unable to parse offset %v of %v.%v - %w", r.address_offset, p.name, r.name, err)
+		}
+		size = max(size, off+4)
+	}
+	return size, true, nil
+}
+
+// all the peripherals sorted by base address, with the gaps between them and any overlaps flagged
+func build_memmap() ([]memmap_entry, error) {
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+
+	names := make(map[int]string)
+	for _, p := range periphs {
+		names[p.id] = p.name
+	}
+
+	var pes []memmap_entry
+	for _, p := range periphs {
+		base, err := parse_number(p.base_address)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse base address %v of %v - %w", p.base_address, p.name, err)
+		}
+		size, est, err := periph_size(p)
+		if err != nil {
+			return nil, err
+		}
+		pe := memmap_entry{Kind: "peripheral", Name: p.name, Base: Hex(base), Size: Hex(size), End: Hex(base + size - 1), Estimated: est}
+		if size == 0 {
+			pe.End = Hex(base)
+		}
+		if p.derived_from.Valid {
+			pe.DerivedFrom = names[p.derived_from.V]
+		}
+		pes = append(pes, pe)
+	}
+
+	sort.SliceStable(pes, func(i, j int) bool {
+		return pes[i].Base < pes[j].Base
+	})
+
+	return memmap_layout(pes), nil
+}
+
+// the peripherals sorted by base address with the gaps between them added and the overlaps flagged
+// a peripheral with a size of 0 takes up no space, so the gap after it starts at its base
+func memmap_layout(pes []memmap_entry) []memmap_entry {
+	var entries []memmap_entry
+	var next Hex
+	for i := range pes {
+		// flag all the previous blocks that this one overlaps, and any gap after the highest end so far
+		for j := 0; j < i; j++ {
+			if pes[j].Size > 0 && pes[j].End >= pes[i].Base {
+				pes[i].Overlaps = append(pes[i].Overlaps, pes[j].Name)
+			}
+		}
+		if i > 0 && pes[i].Base > next {
+			entries = append(entries, memmap_entry{Kind: "gap", Base: next, Size: pes[i].Base - next, End: pes[i].Base - 1})
+		}
+		entries = append(entries, pes[i])
+
+		if pes[i].Size > 0 {
+			next = max(next, pes[i].End+1)
+		} else {
+			next = max(next, pes[i].Base)
+		}
+	}
+
+	return entries
+}
+
+// show all the peripherals sorted by base address with their size, end address and any gaps or overlaps
+func Memmap() error {
+	entries, err := build_memmap()
+	if err != nil {
+		return err
+	}
+
+	if !text_output() {
+		var rows [][]string
+		for _, e := range entries {
+			rows = append(rows, []string{e.Kind, e.Name, fmt.Sprintf("0x%08X", e.Base), fmt.Sprintf("0x%X", e.Size), fmt.Sprintf("0x%08X", e.End),
+				fmt.Sprint(e.Estimated), e.DerivedFrom, strings.Join(e.Overlaps, " ")})
+		}
+		header := []string{"kind", "name", "base", "size", "end", "estimated", "derived_from", "overlaps"}
+		return write_structured(entries, header, rows)
+	}
+
+	fmt.Println("Memory map for MPU:", getMPU())
+	for _, e := range entries {
+		if e.Kind == "gap" {
+			fmt.Printf("  0x%08X - 0x%08X  %10v  -- unused gap --\n", e.Base, e.End, fmt.Sprintf("0x%X", e.Size))
+			continue
+		}
+		size := fmt.Sprintf("0x%X", e.Size)
+		if e.Estimated {
+			size = "~" + size
+		}
+		s := fmt.Sprintf("0x%08X - 0x%08X  %10v  %v", e.Base, e.End, size, e.Name)
+		if e.Size == 0 {
+			// it has no address range
+			s = fmt.Sprintf("0x%08X%15v%10v  %v", e.Base, "", size, e.Name)
+		}
+		if e.DerivedFrom != "" {
+			s += " (derived from " + e.DerivedFrom + ")"
+		}
+		if len(e.Overlaps) > 0 {
+			s += " ** OVERLAPS " + strings.Join(e.Overlaps, ", ") + " **"
+		}
+		fmt.Println(" ", s)
+	}
+	if verbose {
+		fmt.Println("\n~ means the size is estimated from the last register as the database has no address block sizes")
+	}
+
+	return nil
+}
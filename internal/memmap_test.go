@@ -0,0 +1,71 @@
+package svd_lookup
+
+import (
+	"slices"
+	"testing"
+)
+
+// where a gap after the entry would start, an entry with no size takes up no space
+func gap_start(e memmap_entry) Hex {
+	if e.Size == 0 {
+		return e.Base
+	}
+	return e.End + 1
+}
+
+func TestBuildMemmap(t *testing.T) {
+	entries, err := build_memmap()
+	if err != nil {
+		t.Fatalf(`build_memmap() = %v, want nil`, err)
+	}
+
+	var last Hex
+	found := false
+	for i, e := range entries {
+		if e.Base < last {
+			t.Errorf(`build_memmap() entry %v %v is not sorted by base address`, i, e.Name)
+		}
+		last = e.Base
+
+		if e.Kind == "gap" && (i == 0 || gap_start(entries[i-1]) != e.Base) {
+			t.Errorf(`build_memmap() gap at 0x%08X does not follow the previous block`, uint64(e.Base))
+		}
+
+		if e.Name == "TIMER1" {
+			found = true
+			if e.Base != 0x40008000 || e.Size != 0xFFF || e.Estimated || e.DerivedFrom != "TIMER0" || len(e.Overlaps) != 0 {
+				t.Errorf(`build_memmap() TIMER1 = %+v, want base 0x40008000 size 0xFFF derived from TIMER0`, e)
+			}
+		}
+	}
+
+	if !found {
+		t.Errorf(`build_memmap() did not have TIMER1`)
+	}
+}
+
+func TestMemmapLayoutZeroSize(t *testing.T) {
+	pes := []memmap_entry{
+		{Kind: "peripheral", Name: "SPARE_IRQ", Base: 0x0, Size: 0x0, End: 0x0},
+		{Kind: "peripheral", Name: "ROM", Base: 0x100, Size: 0x100, End: 0x1FF},
+		{Kind: "peripheral", Name: "EMPTY", Base: 0x400, Size: 0x0, End: 0x400},
+		{Kind: "peripheral", Name: "RAM", Base: 0x1000, Size: 0x1000, End: 0x1FFF},
+	}
+
+	want := []memmap_entry{
+		pes[0],
+		{Kind: "gap", Base: 0x0, Size: 0x100, End: 0xFF},
+		pes[1],
+		{Kind: "gap", Base: 0x200, Size: 0x200, End: 0x3FF},
+		pes[2],
+		{Kind: "gap", Base: 0x400, Size: 0xC00, End: 0xFFF},
+		pes[3],
+	}
+
+	got := memmap_layout(slices.Clone(pes))
+	if !slices.EqualFunc(got, want, func(a, b memmap_entry) bool {
+		return a.Kind == b.Kind && a.Name == b.Name && a.Base == b.Base && a.Size == b.Size && a.End == b.End && len(a.Overlaps) == 0
+	}) {
+		t.Errorf(`memmap_layout() = %+v, want %+v`, got, want)
+	}
+}
@@ -0,0 +1,230 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The machine readable model of the database, this is what the json, yaml and csv output formats write.
+// The structure is documented in the README and should only be added to so scripts using it do not break.
+
+// addresses, sizes and masks are shown in hex
+type Hex uint64
+
+func (h Hex) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("0x%08X", uint64(h))), nil
+}
+
+// so the output can be read back, eg by clients of serve
+func (h *Hex) UnmarshalText(b []byte) error {
+	v, err := parse_number(string(b))
+	if err != nil {
+		return fmt.Errorf("Unable to parse hex %v - %w", string(b), err)
+	}
+	*h = Hex(v)
+	return nil
+}
+
+type DeviceInfo struct {
+	Name        string           `json:"name" yaml:"name"`
+	Description string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Peripherals []PeripheralInfo `json:"peripherals" yaml:"peripherals"`
+}
+
+type PeripheralInfo struct {
+	Name        string          `json:"name" yaml:"name"`
+	BaseAddress Hex             `json:"base_address" yaml:"base_address"`
+	Description string          `json:"description,omitempty" yaml:"description,omitempty"`
+	DerivedFrom string          `json:"derived_from,omitempty" yaml:"derived_from,omitempty"`
+	BlockSize   *Hex            `json:"block_size,omitempty" yaml:"block_size,omitempty"`
+	GroupName   string          `json:"group_name,omitempty" yaml:"group_name,omitempty"`
+	Interrupts  []InterruptInfo `json:"interrupts,omitempty" yaml:"interrupts,omitempty"`
+	Registers   []RegisterInfo  `json:"registers,omitempty" yaml:"registers,omitempty"`
+}
+
+type RegisterInfo struct {
+	Name          string      `json:"name" yaml:"name"`
+	AddressOffset Hex         `json:"address_offset" yaml:"address_offset"`
+	Address       Hex         `json:"address" yaml:"address"`
+	ResetValue    *Hex        `json:"reset_value,omitempty" yaml:"reset_value,omitempty"`
+	Size          int         `json:"size,omitempty" yaml:"size,omitempty"`
+	Access        string      `json:"access,omitempty" yaml:"access,omitempty"`
+	Description   string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Fields        []FieldInfo `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+type FieldInfo struct {
+	Name        string     `json:"name" yaml:"name"`
+	BitOffset   int        `json:"bit_offset" yaml:"bit_offset"`
+	NumBits     int        `json:"num_bits" yaml:"num_bits"`
+	Mask        Hex        `json:"mask" yaml:"mask"`
+	Access      string     `json:"access,omitempty" yaml:"access,omitempty"`
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Enums       []EnumInfo `json:"enums,omitempty" yaml:"enums,omitempty"`
+}
+
+type InterruptInfo struct {
+	Name        string `json:"name" yaml:"name"`
+	Value       int    `json:"value" yaml:"value"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type EnumInfo struct {
+	Name        string `json:"name" yaml:"name"`
+	Value       uint64 `json:"value" yaml:"value"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// descriptions in the SVD often have line breaks and extra spaces in them
+func clean_description(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// the device with no peripherals
+func device_info() (DeviceInfo, error) {
+	mpus, err := fetch_mpus()
+	if err != nil {
+		return DeviceInfo{}, fmt.Errorf("failed to fetch MPUs - %w", err)
+	}
+	// just use the first one, as OpenDatabase does
+	return DeviceInfo{Name: mpus[0].name, Description: clean_description(mpus[0].description.V), Peripherals: []PeripheralInfo{}}, nil
+}
+
+// the device with the peripherals matching any of the patterns, or all of them, and their registers
+// derived peripherals have the registers of the one they are derived from
+func collect_device(periph_pats []string) (DeviceInfo, error) {
+	scope, err := compile_patterns(periph_pats)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+
+	d, err := device_info()
+	if err != nil {
+		return d, err
+	}
+
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return d, fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+
+	for _, p := range periphs {
+		if !match_any(scope, p.name) {
+			continue
+		}
+		regs, err := fetch_registers_with_fields(p)
+		if err != nil {
+			return d, fmt.Errorf("Failed to collect registers for peripheral %v: %w", p.name, err)
+		}
+		pi, err := peripheral_info_with(p, regs)
+		if err != nil {
+			return d, err
+		}
+		d.Peripherals = append(d.Peripherals, pi)
+	}
+
+	if len(d.Peripherals) == 0 {
+		return d, fmt.Errorf("No peripherals match %v", strings.Join(periph_pats, " "))
+	}
+
+	return d, nil
+}
+
+// the peripheral without its registers, derived_from is looked up from the id
+func peripheral_info(p Peripheral) (PeripheralInfo, error) {
+	base, err := parse_number(p.base_address)
+	if err != nil {
+		return PeripheralInfo{}, fmt.Errorf("Unable to parse base address %v of %v - %w", p.base_address, p.name, err)
+	}
+
+	pi := PeripheralInfo{Name: p.name, BaseAddress: Hex(base), Description: clean_description(p.description.V), GroupName: p.group_name.V}
+
+	if p.derived_from.Valid {
+		dp, err := fetch_peripheral(p.derived_from.V)
+		if err != nil {
+			return pi, fmt.Errorf("No derived peripheral with id: %v found: %w", p.derived_from.V, err)
+		}
+		pi.DerivedFrom = dp.name
+	}
+
+	if p.block_size.Valid {
+		size, _, err := periph_size(p)
+		if err != nil {
+			return pi, err
+		}
+		h := Hex(size)
+		pi.BlockSize = &h
+	}
+
+	if has_interrupts {
+		irqs, err := fetch_interrupts(p.id)
+		if err != nil {
+			return pi, err
+		}
+		for _, irq := range irqs {
+			pi.Interrupts = append(pi.Interrupts, InterruptInfo{Name: irq.name, Value: irq.value, Description: clean_description(irq.description.V)})
+		}
+	}
+
+	return pi, nil
+}
+
+// the register and its fields if they have been collected
+func register_info(base uint64, r Register) (RegisterInfo, error) {
+	off, err := parse_number(r.address_offset)
+	if err != nil {
+		return RegisterInfo{}, fmt.Errorf("Unable to parse offset %v of %v - %w", r.address_offset, r.name, err)
+	}
+
+	ri := RegisterInfo{Name: r.name, AddressOffset: Hex(off), Address: Hex(base + off), Description: clean_description(r.description.V)}
+
+	if r.reset_value.Valid {
+		if v, err := parse_number(r.reset_value.V); err == nil {
+			h := Hex(v)
+			ri.ResetValue = &h
+		}
+	}
+
+	if r.size.Valid {
+		if v, err := parse_number(r.size.V); err == nil {
+			ri.Size = int(v)
+		}
+	}
+	ri.Access = r.access.V
+
+	if r.fields != nil {
+		for _, f := range *r.fields {
+			ri.Fields = append(ri.Fields, field_info(f))
+		}
+	}
+
+	return ri, nil
+}
+
+func field_info(f Field) FieldInfo {
+	fi := FieldInfo{Name: f.name, BitOffset: f.bit_offset, NumBits: f.num_bits, Mask: Hex(field_mask(f)), Access: f.access.V, Description: clean_description(f.description.V)}
+	if f.enums != nil {
+		for _, e := range *f.enums {
+			fi.Enums = append(fi.Enums, EnumInfo{Name: e.name, Value: e.value, Description: clean_description(e.description.V)})
+		}
+	}
+	return fi
+}
+
+// the peripheral with all the given registers
+func peripheral_info_with(p Peripheral, regs []Register) (PeripheralInfo, error) {
+	pi, err := peripheral_info(p)
+	if err != nil {
+		return pi, err
+	}
+
+	for _, r := range regs {
+		ri, err := register_info(uint64(pi.BaseAddress), r)
+		if err != nil {
+			return pi, err
+		}
+		pi.Registers = append(pi.Registers, ri)
+	}
+
+	return pi, nil
+}
@@ -0,0 +1,63 @@
+package svd_lookup
+
+import (
+	"testing"
+)
+
+func TestPeripheralInfo(t *testing.T) {
+	p, err := fetch_peripheral_by_name("TIMER1")
+	if err != nil {
+		t.Fatalf(`fetch_peripheral_by_name("TIMER1") = %v, want nil`, err)
+	}
+	pr, err := collect_registers(p.name)
+	if err != nil {
+		t.Fatalf(`collect_registers("TIMER1") = %v, want nil`, err)
+	}
+
+	pi, err := peripheral_info_with(p, *pr.registers)
+	if err != nil {
+		t.Fatalf(`peripheral_info_with(TIMER1) = %v, want nil`, err)
+	}
+	if pi.BaseAddress != 0x40008000 || pi.DerivedFrom != "TIMER0" || len(pi.Registers) != 11 {
+		t.Errorf(`peripheral_info_with(TIMER1) = %v %v %v registers, want 0x40008000 TIMER0 11 registers`,
+			pi.BaseAddress, pi.DerivedFrom, len(pi.Registers))
+	}
+	// derived peripherals have their own interrupts
+	if len(pi.Interrupts) != 1 || pi.Interrupts[0].Name != "TIMER1" || pi.Interrupts[0].Value != 2 {
+		t.Errorf(`peripheral_info_with(TIMER1) interrupts = %+v, want TIMER1 2`, pi.Interrupts)
+	}
+
+	for _, r := range pi.Registers {
+		if r.Name == "MCR" {
+			if r.AddressOffset != 0x14 || r.Address != 0x40008014 || r.ResetValue == nil || *r.ResetValue != 0 {
+				t.Errorf(`MCR = %+v, want offset 0x14 address 0x40008014 reset 0`, r)
+			}
+			if len(r.Fields) == 0 || r.Fields[0].Name != "MR0I" || r.Fields[0].Mask != 1 || len(r.Fields[0].Enums) != 2 {
+				t.Errorf(`MCR fields = %+v, want MR0I with mask 1 and 2 enums`, r.Fields)
+			}
+		}
+	}
+}
+
+func TestDeviceCsvRows(t *testing.T) {
+	d := DeviceInfo{Name: "test", Peripherals: []PeripheralInfo{
+		{Name: "P1", BaseAddress: 0x1000},
+		{Name: "P2", BaseAddress: 0x2000, Registers: []RegisterInfo{
+			{Name: "R1", AddressOffset: 4, Address: 0x2004},
+			{Name: "R2", AddressOffset: 8, Address: 0x2008, Fields: []FieldInfo{{Name: "F1"}, {Name: "F2"}}},
+		}},
+	}}
+
+	rows := device_csv_rows(d)
+	if len(rows) != 4 {
+		t.Fatalf(`device_csv_rows() = %v rows, want 4`, len(rows))
+	}
+	for _, r := range rows {
+		if len(r) != len(device_csv_header) {
+			t.Errorf(`device_csv_rows() row %v has %v columns, want %v`, r, len(r), len(device_csv_header))
+		}
+	}
+	if rows[3][3] != "R2" || rows[3][7] != "F2" {
+		t.Errorf(`device_csv_rows() last row = %v, want R2 F2`, rows[3])
+	}
+}
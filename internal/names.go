@@ -0,0 +1,103 @@
+package svd_lookup
+
+import (
+	"fmt"
+)
+
+// lists of names used for completion in the shell
+
+// names of all the peripherals
+func PeripheralNames() ([]string, error) {
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+
+	var names []string
+	for _, p := range periphs {
+		names = append(names, p.name)
+	}
+	return names, nil
+}
+
+// names of all the peripheral groups
+func GroupNames() ([]string, error) {
+	groups, err := fetch_groups()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	return names, nil
+}
+
+// names of the registers of the peripheral
+func RegisterNames(periph string) ([]string, error) {
+	p, err := fetch_peripheral_by_name(periph)
+	if err != nil {
+		return nil, fmt.Errorf("No peripheral with name like: %v - %w", periph, err)
+	}
+
+	id := p.id
+	if p.derived_from.Valid {
+		id = p.derived_from.V
+	}
+
+	regs, err := fetch_registers(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, r := range regs {
+		names = append(names, r.name)
+	}
+	return names, nil
+}
+
+// names of the fields of the register in the peripheral
+func FieldNames(periph string, reg string) ([]string, error) {
+	pr, err := collect_registers(periph)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to collect registers for peripheral %v: %w", periph, err)
+	}
+
+	r, err := find_register(pr, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if r.fields != nil {
+		for _, f := range *r.fields {
+			names = append(names, f.name)
+		}
+	}
+	return names, nil
+}
+
+// the actual name of the peripheral, as it may have been given in a different case
+func PeripheralName(periph string) (string, error) {
+	p, err := fetch_peripheral_by_name(periph)
+	if err != nil {
+		return "", fmt.Errorf("No peripheral with name like: %v - %w", periph, err)
+	}
+	return p.name, nil
+}
+
+// the actual name of the register in the peripheral
+func RegisterName(periph string, reg string) (string, error) {
+	pr, err := collect_registers(periph)
+	if err != nil {
+		return "", fmt.Errorf("Failed to collect registers for peripheral %v: %w", periph, err)
+	}
+
+	r, err := find_register(pr, reg)
+	if err != nil {
+		return "", err
+	}
+	return r.name, nil
+}
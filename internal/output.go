@@ -0,0 +1,120 @@
+package svd_lookup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the output format for the query commands, text is the human readable output
+// the others write the data in the model as json, yaml or csv
+var output_format string = "text"
+
+var Formats = []string{"text", "json", "yaml", "csv"}
+
+func SetFormat(f string) error {
+	for _, ff := range Formats {
+		if f == ff {
+			output_format = f
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown format %v, must be one of %v", f, strings.Join(Formats, ", "))
+}
+
+func text_output() bool {
+	return output_format == "text"
+}
+
+// write v as json or yaml, or as csv with the given header and rows
+func write_structured(v any, header []string, rows [][]string) error {
+	switch output_format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case "yaml":
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write(header)
+		w.WriteAll(rows)
+		return w.Error()
+
+	default:
+		return fmt.Errorf("Unknown format %v", output_format)
+	}
+}
+
+// the csv output of the device is one row for each field, or for each register with no fields,
+// or for each peripheral with no registers
+var device_csv_header = []string{"peripheral", "base_address", "derived_from", "register", "address_offset", "address",
+	"reset_value", "field", "bit_offset", "num_bits", "mask", "enums", "description"}
+
+func device_csv_rows(d DeviceInfo) [][]string {
+	var rows [][]string
+	hex := func(h Hex) string { return fmt.Sprintf("0x%08X", uint64(h)) }
+
+	for _, p := range d.Peripherals {
+		prow := []string{p.Name, hex(p.BaseAddress), p.DerivedFrom}
+		if len(p.Registers) == 0 {
+			rows = append(rows, append(prow, "", "", "", "", "", "", "", "", "", p.Description))
+			continue
+		}
+
+		for _, r := range p.Registers {
+			reset := ""
+			if r.ResetValue != nil {
+				reset = hex(*r.ResetValue)
+			}
+			rrow := append(append([]string{}, prow...), r.Name, hex(r.AddressOffset), hex(r.Address), reset)
+			if len(r.Fields) == 0 {
+				rows = append(rows, append(rrow, "", "", "", "", "", r.Description))
+				continue
+			}
+
+			for _, f := range r.Fields {
+				var enums []string
+				for _, e := range f.Enums {
+					enums = append(enums, fmt.Sprintf("%v=%v", e.Name, e.Value))
+				}
+				frow := append(append([]string{}, rrow...), f.Name, fmt.Sprint(f.BitOffset), fmt.Sprint(f.NumBits),
+					hex(f.Mask), strings.Join(enums, " "), f.Description)
+				rows = append(rows, frow)
+			}
+		}
+	}
+
+	return rows
+}
+
+func write_device(d DeviceInfo) error {
+	return write_structured(d, device_csv_header, device_csv_rows(d))
+}
+
+// write the device with just the one peripheral
+func write_peripheral(p Peripheral, regs []Register) error {
+	d, err := device_info()
+	if err != nil {
+		return err
+	}
+
+	pi, err := peripheral_info_with(p, regs)
+	if err != nil {
+		return err
+	}
+	d.Peripherals = append(d.Peripherals, pi)
+
+	return write_device(d)
+}
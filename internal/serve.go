@@ -0,0 +1,293 @@
+package svd_lookup
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// local http server with a JSON API and a small web browser of the database
+// the database is opened read only and database/sql is safe for concurrent use,
+// the only shared state is the address map which is built once on the first /addr request
+
+//go:embed web/index.html
+var index_html []byte
+
+// the most results /search will return
+const max_search_results = 500
+
+type MPUInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type SearchResult struct {
+	Kind       string `json:"kind"`
+	Peripheral string `json:"peripheral"`
+	Register   string `json:"register,omitempty"`
+	Field      string `json:"field,omitempty"`
+	Address    Hex    `json:"address"`
+}
+
+type DecodedField struct {
+	Name    string  `json:"name"`
+	Bits    string  `json:"bits"`
+	Value   uint64  `json:"value"`
+	Enum    string  `json:"enum,omitempty"`
+	Reset   *uint64 `json:"reset,omitempty"`
+	Differs bool    `json:"differs_from_reset"`
+}
+
+type DecodeResult struct {
+	Peripheral   string         `json:"peripheral"`
+	Register     string         `json:"register"`
+	Address      Hex            `json:"address"`
+	Value        Hex            `json:"value"`
+	Fields       []DecodedField `json:"fields"`
+	ReservedBits Hex            `json:"reserved_bits,omitempty"`
+}
+
+type AddressResult struct {
+	Address    Hex    `json:"address"`
+	Peripheral string `json:"peripheral"`
+	Register   string `json:"register,omitempty"`
+	Offset     Hex    `json:"offset"`
+}
+
+// an error with the http status to return for it
+type api_error struct {
+	status int
+	err error
+}
+
+func (e api_error) Error() string {
+	return e.err.Error()
+}
+
+func bad_request(format string, a ...any) error {
+	return api_error{http.StatusBadRequest, fmt.Errorf(format, a...)}
+}
+
+type api_server struct {
+	addr_once sync.Once
+	addr_map address_map
+	addr_err error
+}
+
+// the http handler for the API and web UI, the database must already be open
+func NewHandler() http.Handler {
+	s := &api_server{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index_html)
+	})
+	mux.HandleFunc("GET /mpus", api(s.mpus))
+	mux.HandleFunc("GET /peripherals", api(s.peripherals))
+	mux.HandleFunc("GET /peripherals/{name}", api(s.peripheral))
+	mux.HandleFunc("GET /search", api(s.search))
+	mux.HandleFunc("GET /decode", api(s.decode))
+	mux.HandleFunc("GET /addr", api(s.addr))
+	return mux
+}
+
+// wraps an API call, writing its result or error as json
+func api(fn func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, err := fn(r)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			status := http.StatusInternalServerError
+			var ae api_error
+			switch {
+			case errors.As(err, &ae):
+				status = ae.status
+			case errors.Is(err, sql.ErrNoRows):
+				status = http.StatusNotFound
+			}
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+	}
+}
+
+func (s *api_server) mpus(r *http.Request) (any, error) {
+	mpus, err := fetch_mpus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch MPUs - %w", err)
+	}
+	infos := []MPUInfo{}
+	for _, m := range mpus {
+		infos = append(infos, MPUInfo{Name: m.name, Description: clean_description(m.description.V)})
+	}
+	return infos, nil
+}
+
+func (s *api_server) peripherals(r *http.Request) (any, error) {
+	periphs, err := fetch_peripherals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peripherals - %w", err)
+	}
+	infos := []PeripheralInfo{}
+	for _, p := range periphs {
+		pi, err := peripheral_info(p)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, pi)
+	}
+	return infos, nil
+}
+
+// the peripheral with all its registers and fields, derived peripherals have the registers they are derived from
+// the name in the path has to match exactly, it is not a pattern
+func (s *api_server) peripheral(r *http.Request) (any, error) {
+	name := r.PathValue("name")
+	p, err := fetch_peripheral_by_exact_name(name)
+	if err != nil {
+		return nil, fmt.Errorf("Peripheral %v not found: %w", name, err)
+	}
+	regs, err := fetch_registers_with_fields(p)
+	if err != nil {
+		return nil, err
+	}
+	return peripheral_info_with(p, regs)
+}
+
+// peripherals, registers and fields with q in their name
+func (s *api_server) search(r *http.Request) (any, error) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		return nil, bad_request("search needs q=text")
+	}
+
+	matches, err := fetch_name_matches(q)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	for _, m := range matches[:min(len(matches), max_search_results)] {
+		base, err := parse_number(m.base_address)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse base address %v of %v - %w", m.base_address, m.periph, err)
+		}
+		sr := SearchResult{Kind: "peripheral", Peripheral: m.periph, Register: m.reg, Field: m.field, Address: Hex(base)}
+		if m.reg != "" {
+			off, err := parse_number(m.address_offset)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse offset %v of %v.%v - %w", m.address_offset, m.periph, m.reg, err)
+			}
+			sr.Kind = "register"
+			sr.Address += Hex(off)
+		}
+		if m.field != "" {
+			sr.Kind = "field"
+		}
+		results = append(results, sr)
+	}
+	return results, nil
+}
+
+// decode a register value, the register is either peripheral=X&register=Y or reg=X.Y
+func (s *api_server) decode(r *http.Request) (any, error) {
+	q := r.URL.Query()
+	periph, reg := q.Get("peripheral"), q.Get("register")
+	if spec := q.Get("reg"); spec != "" {
+		periph, reg, _ = strings.Cut(spec, ".")
+	}
+	if periph == "" || reg == "" || q.Get("value") == "" {
+		return nil, bad_request("decode needs peripheral=X&register=Y or reg=X.Y and value=N")
+	}
+
+	v, err := parse_number(q.Get("value"))
+	if err != nil {
+		return nil, bad_request("Unable to parse value %v - %w", q.Get("value"), err)
+	}
+
+	pr, err := collect_registers(periph)
+	if err != nil {
+		return nil, err
+	}
+	rg, err := find_register(pr, reg)
+	if err != nil {
+		return nil, api_error{http.StatusNotFound, err}
+	}
+	off, err := parse_number(rg.address_offset)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse offset %v of %v.%v - %w", rg.address_offset, pr.name, rg.name, err)
+	}
+	base, err := parse_number(pr.base_address)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse base address %v of %v - %w", pr.base_address, pr.name, err)
+	}
+
+	dr := DecodeResult{Peripheral: pr.name, Register: rg.name, Address: Hex(base + off), Value: Hex(v),
+		Fields: []DecodedField{}, ReservedBits: Hex(v & reserved_mask(rg))}
+	for _, fv := range decode_register(rg, v) {
+		df := DecodedField{Name: fv.field.name, Bits: bit_range(fv.field), Value: fv.value, Enum: enum_name(fv.field, fv.value)}
+		if fv.has_reset {
+			reset := fv.reset
+			df.Reset = &reset
+			df.Differs = fv.value != fv.reset
+		}
+		dr.Fields = append(dr.Fields, df)
+	}
+	return dr, nil
+}
+
+// the peripheral and register at the address
+func (s *api_server) addr(r *http.Request) (any, error) {
+	a := r.URL.Query().Get("a")
+	if a == "" {
+		return nil, bad_request("addr needs a=address")
+	}
+	addr, err := parse_number(a)
+	if err != nil {
+		return nil, bad_request("Unable to parse address %v - %w", a, err)
+	}
+
+	s.addr_once.Do(func() {
+		s.addr_map, s.addr_err = build_address_map()
+	})
+	if s.addr_err != nil {
+		return nil, s.addr_err
+	}
+
+	// a register containing the address, the word it is in if it is not word aligned
+	word := addr &^ 3
+	if locs := s.addr_map.regs[word]; len(locs) > 0 {
+		return AddressResult{Address: Hex(addr), Peripheral: locs[0].periph, Register: locs[0].reg.name, Offset: Hex(addr - locs[0].base)}, nil
+	}
+	for _, span := range s.addr_map.spans {
+		if addr >= span.base && addr < span.end {
+			return AddressResult{Address: Hex(addr), Peripheral: span.name, Offset: Hex(addr - span.base)}, nil
+		}
+	}
+	return nil, api_error{http.StatusNotFound, fmt.Errorf("No peripheral at address 0x%08X", addr)}
+}
+
+// serve the API and web UI until the server fails
+// with timeouts so slow or idle clients can not hold connections open
+func Serve(listen string) error {
+	fmt.Printf("Serving MPU: %v on http://%v/\n", getMPU(), listen)
+	srv := &http.Server{
+		Addr:              listen,
+		Handler:           NewHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,119 @@
+package svd_lookup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// get the url from the server and decode the json result into v, returns the status
+func get_json(t *testing.T, srv *httptest.Server, url string, v any) int {
+	t.Helper()
+	resp, err := srv.Client().Get(srv.URL + url)
+	if err != nil {
+		t.Fatalf(`GET %v = %v, want nil`, url, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf(`GET %v returned bad json - %v`, url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestServe(t *testing.T) {
+	srv := httptest.NewServer(NewHandler())
+	defer srv.Close()
+
+	var mpus []MPUInfo
+	if st := get_json(t, srv, "/mpus", &mpus); st != http.StatusOK || len(mpus) != 1 || mpus[0].Name != "LPC176x5x" {
+		t.Errorf(`/mpus = %v %+v, want 200 LPC176x5x`, st, mpus)
+	}
+
+	var periphs []PeripheralInfo
+	if st := get_json(t, srv, "/peripherals", &periphs); st != http.StatusOK || len(periphs) < 10 || periphs[0].Registers != nil {
+		t.Errorf(`/peripherals = %v %v peripherals, want 200 and peripherals without registers`, st, len(periphs))
+	}
+
+	var pi PeripheralInfo
+	if st := get_json(t, srv, "/peripherals/timer1", &pi); st != http.StatusOK || pi.Name != "TIMER1" || pi.DerivedFrom != "TIMER0" || len(pi.Registers) != 11 {
+		t.Errorf(`/peripherals/timer1 = %v %v %v %v registers, want 200 TIMER1 derived from TIMER0 with 11 registers`,
+			st, pi.Name, pi.DerivedFrom, len(pi.Registers))
+	}
+
+	var e map[string]string
+	if st := get_json(t, srv, "/peripherals/NOTHERE", &e); st != http.StatusNotFound || e["error"] == "" {
+		t.Errorf(`/peripherals/NOTHERE = %v %v, want 404 with an error`, st, e)
+	}
+
+	// the name is not a pattern
+	for _, url := range []string{"/peripherals/UART_", "/peripherals/UART%25"} {
+		var e map[string]string
+		if st := get_json(t, srv, url, &e); st != http.StatusNotFound || e["error"] == "" {
+			t.Errorf(`%v = %v %v, want 404 with an error`, url, st, e)
+		}
+	}
+
+	var results []SearchResult
+	get_json(t, srv, "/search?q=mr0i", &results)
+	found := false
+	for _, r := range results {
+		if r.Kind == "field" && r.Peripheral == "TIMER1" && r.Register == "MCR" && r.Address == 0x40008014 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf(`/search?q=mr0i = %+v, want TIMER1.MCR.MR0I at 0x40008014`, results)
+	}
+
+	var dr DecodeResult
+	if st := get_json(t, srv, "/decode?reg=UART0.LCR&value=0x83", &dr); st != http.StatusOK || dr.Address != 0x4000C00C || len(dr.Fields) == 0 {
+		t.Fatalf(`/decode UART0.LCR = %v %+v, want 200 at 0x4000C00C`, st, dr)
+	}
+	if f := dr.Fields[0]; f.Name != "WLS" || f.Value != 3 || f.Enum != "8_BIT_CHARACTER_LENG" || !f.Differs {
+		t.Errorf(`/decode UART0.LCR WLS = %+v, want 3 8_BIT_CHARACTER_LENG differing from reset`, f)
+	}
+	if st := get_json(t, srv, "/decode?reg=UART0.LCR&value=xyz", &e); st != http.StatusBadRequest {
+		t.Errorf(`/decode with a bad value = %v, want 400`, st)
+	}
+
+	var ar AddressResult
+	if st := get_json(t, srv, "/addr?a=0x40008016", &ar); st != http.StatusOK || ar.Register != "MCR" || ar.Offset != 0x16 {
+		t.Errorf(`/addr?a=0x40008016 = %v %+v, want MCR at offset 0x16`, st, ar)
+	}
+	if st := get_json(t, srv, "/addr?a=0x10", &e); st != http.StatusNotFound {
+		t.Errorf(`/addr?a=0x10 = %v, want 404`, st)
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/")
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf(`GET / = %v %v, want the html page`, resp, err)
+	}
+	resp.Body.Close()
+}
+
+func TestServeConcurrent(t *testing.T) {
+	srv := httptest.NewServer(NewHandler())
+	defer srv.Close()
+
+	urls := []string{"/peripherals", "/peripherals/UART0", "/search?q=CR", "/decode?reg=TIMER1.MCR&value=1", "/addr?a=0x4000C00C"}
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			resp, err := srv.Client().Get(srv.URL + url)
+			if err != nil {
+				t.Errorf(`GET %v = %v, want nil`, url, err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf(`GET %v = %v, want 200`, url, resp.StatusCode)
+			}
+		}(urls[i%len(urls)])
+	}
+	wg.Wait()
+}
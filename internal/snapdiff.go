@@ -0,0 +1,136 @@
+package svd_lookup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// value of a field with its enumerated name if known
+func field_value_string(f Field, v uint64) string {
+	if en := enum_name(f, v); en != "" {
+		return fmt.Sprintf("0x%X %v", v, en)
+	}
+	return fmt.Sprintf("0x%X", v)
+}
+
+// print out just the fields that differ between the old and new values of the register
+func print_field_diffs(w io.Writer, r Register, old_value uint64, new_value uint64, indent string) {
+	old_fvs := decode_register(r, old_value)
+	new_fvs := decode_register(r, new_value)
+
+	for i, nfv := range new_fvs {
+		ofv := old_fvs[i]
+		if ofv.value != nfv.value {
+			fmt.Fprintf(w, "%v%v%v: %v -> %v\n", indent, nfv.field.name, bit_range(nfv.field),
+				field_value_string(ofv.field, ofv.value), field_value_string(nfv.field, nfv.value))
+		}
+	}
+
+	rm := reserved_mask(r)
+	if old_value&rm != new_value&rm {
+		fmt.Fprintf(w, "%vreserved bits: 0x%08X -> 0x%08X\n", indent, old_value&rm, new_value&rm)
+	}
+}
+
+// compare two memory dumps and report the registers and fields that differ
+func SnapDiff(before string, after string, base string) error {
+	return snap_diff(os.Stdout, before, after, base)
+}
+
+// the number of addresses in from that are not in to
+func count_missing(from map[uint64]uint64, to map[uint64]uint64) int {
+	n := 0
+	for a := range from {
+		if _, ok := to[a]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+func snap_diff(out io.Writer, before string, after string, base string) error {
+	old_words, err := load_dump(before, base)
+	if err != nil {
+		return err
+	}
+	new_words, err := load_dump(after, base)
+	if err != nil {
+		return err
+	}
+
+	am, err := build_address_map()
+	if err != nil {
+		return err
+	}
+
+	old_values := make(map[uint64]uint64)
+	for _, w := range old_words {
+		old_values[w.addr] = w.value
+	}
+
+	sort.SliceStable(new_words, func(i, j int) bool {
+		return new_words[i].addr < new_words[j].addr
+	})
+
+	fmt.Fprintln(out, "Differences from", before, "to", after, "for MPU:", getMPU())
+
+	current := "-"
+	ndiffs := 0
+	new_values := make(map[uint64]uint64)
+	for _, w := range new_words {
+		new_values[w.addr] = w.value
+		ov, ok := old_values[w.addr]
+		if !ok {
+			continue
+		}
+		if ov == w.value {
+			continue
+		}
+		ndiffs++
+
+		locs := am.regs[w.addr]
+
+		// group by peripheral
+		pname := am.periph_at(w.addr)
+		if len(locs) > 0 {
+			pname = locs[0].periph
+		}
+		if pname != current {
+			if pname == "" {
+				fmt.Fprintln(out, "\nOutside any known peripheral")
+			} else {
+				fmt.Fprintln(out, "\nPeripheral", pname)
+			}
+			current = pname
+		}
+
+		if len(locs) == 0 {
+			fmt.Fprintf(out, "  0x%08X: 0x%08X -> 0x%08X ** not a known register **\n", w.addr, ov, w.value)
+			continue
+		}
+
+		for _, l := range locs {
+			name := l.reg.name
+			if l.periph != pname {
+				name = l.periph + "." + name
+			}
+			fmt.Fprintf(out, "  0x%08X %v: 0x%08X -> 0x%08X\n", w.addr, name, ov, w.value)
+			print_field_diffs(out, l.reg, ov, w.value, "      ")
+		}
+	}
+
+	if ndiffs == 0 {
+		fmt.Fprintln(out, "No differences")
+	}
+	// a dump can have an address more than once so the addresses are counted, not the words
+	if n := count_missing(new_values, old_values); n > 0 {
+		fmt.Fprintf(out, "\n%v words in %v are not in %v\n", n, after, before)
+	}
+	if n := count_missing(old_values, new_values); n > 0 {
+		fmt.Fprintf(out, "\n%v words in %v are not in %v\n", n, before, after)
+	}
+
+	return nil
+}
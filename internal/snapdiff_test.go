@@ -0,0 +1,115 @@
+package svd_lookup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write_dump(t *testing.T, dir string, name string, s string) string {
+	fn := filepath.Join(dir, name)
+	if err := os.WriteFile(fn, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestSnapDiff(t *testing.T) {
+	dir := t.TempDir()
+	// UART0 LCR sets DLAB and a reserved bit in SCR changes, WDT MOD is only in before and UART0 FDR only in after
+	before := write_dump(t, dir, "before.txt", `> mdw 0x4000C00C 1
+0x4000c00c: 00000003
+0x4000c01c: 00000000
+0x40000000: 00000001
+`)
+	after := write_dump(t, dir, "after.txt", `(gdb) x/wx 0x4000C00C
+0x4000c00c <UART0>:	0x00000083
+0x4000c01c:	0x00000000
+0x4000c028:	0x00000010
+`)
+
+	var b bytes.Buffer
+	if err := snap_diff(&b, before, after, ""); err != nil {
+		t.Fatalf(`snap_diff() = %v, want nil`, err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"\nPeripheral UART0\n",
+		"  0x4000C00C LCR: 0x00000003 -> 0x00000083\n",
+		"      DLAB[7]: 0x0 DISABLE_ACCESS_TO_DI -> 0x1 ENABLE_ACCESS_TO_DIV\n",
+		"\n1 words in " + after + " are not in " + before + "\n",
+		"\n1 words in " + before + " are not in " + after + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("snap_diff() does not contain %q\n%v", want, out)
+		}
+	}
+	if strings.Contains(out, "WLS") || strings.Contains(out, "reserved bits") || strings.Contains(out, "SCR") {
+		t.Errorf("snap_diff() shows fields that did not change\n%v", out)
+	}
+	// the word only in one of the dumps is not shown as a difference
+	if strings.Contains(out, "0x40000000") || strings.Contains(out, "0x4000C028") {
+		t.Errorf("snap_diff() shows a word that is only in one dump as a difference\n%v", out)
+	}
+}
+
+func TestSnapDiffRepeatedAddress(t *testing.T) {
+	dir := t.TempDir()
+	// the dumps overlap so LCR is in after twice, and so is FDR which is not in before
+	before := write_dump(t, dir, "before.txt", "0x4000c00c: 00000003\n0x40000000: 00000001\n")
+	after := write_dump(t, dir, "after.txt", "0x4000c00c: 00000003\n0x4000c028: 00000010\n0x4000c00c: 00000003\n0x4000c028: 00000010\n")
+
+	var b bytes.Buffer
+	if err := snap_diff(&b, before, after, ""); err != nil {
+		t.Fatalf(`snap_diff() = %v, want nil`, err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"\n1 words in " + after + " are not in " + before + "\n",
+		"\n1 words in " + before + " are not in " + after + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("snap_diff() does not contain %q\n%v", want, out)
+		}
+	}
+}
+
+func TestSnapDiffReserved(t *testing.T) {
+	dir := t.TempDir()
+	before := write_dump(t, dir, "before.txt", "0x4000c00c: 00000003\n")
+	after := write_dump(t, dir, "after.txt", "0x4000c00c: 00000103\n")
+
+	var b bytes.Buffer
+	if err := snap_diff(&b, before, after, ""); err != nil {
+		t.Fatalf(`snap_diff() = %v, want nil`, err)
+	}
+	out := b.String()
+	if !strings.Contains(out, "  0x4000C00C LCR: 0x00000003 -> 0x00000103\n      reserved bits: 0x00000000 -> 0x00000100\n") {
+		t.Errorf("snap_diff() does not show the reserved bits changing\n%v", out)
+	}
+	if strings.Contains(out, "RESERVED[") || strings.Contains(out, "WLS") {
+		t.Errorf("snap_diff() shows a field for a change in the reserved bits\n%v", out)
+	}
+}
+
+func TestSnapDiffBadDump(t *testing.T) {
+	dir := t.TempDir()
+	good := write_dump(t, dir, "good.txt", "0x4000c00c: 00000003\n")
+	for _, s := range []string{
+		// the address is too big to parse
+		"0x4000c00c0000000000000: 00000003\n",
+		// nothing that looks like a dump
+		"this is not a dump\n",
+	} {
+		bad := write_dump(t, dir, "bad.txt", s)
+		var b bytes.Buffer
+		if err := snap_diff(&b, good, bad, ""); err == nil {
+			t.Errorf(`snap_diff() with %q = nil, want an error`, s)
+		}
+		if err := snap_diff(&b, bad, good, ""); err == nil {
+			t.Errorf(`snap_diff() with %q before = nil, want an error`, s)
+		}
+	}
+}
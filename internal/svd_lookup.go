@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"errors"
+	"strconv"
 	"strings"
 )
 
@@ -17,9 +18,13 @@ import (
 SVD Database schema
 CREATE TABLE `mpus` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `name` varchar(255) NOT NULL UNIQUE, `description` varchar(255));
 CREATE TABLE sqlite_sequence(name,seq);
-CREATE TABLE `peripherals` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `mpu_id` integer, `derived_from_id` integer, `name` varchar(255) NOT NULL UNIQUE, `base_address` varchar(255), `description` varchar(255));
-CREATE TABLE `registers` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `peripheral_id` integer, `name` varchar(255) NOT NULL, `address_offset` varchar(255), `reset_value` varchar(255), `description` varchar(255));
-CREATE TABLE `fields` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `register_id` integer, `name` varchar(255) NOT NULL, `num_bits` integer, `bit_offset` integer, `description` varchar(255));
+CREATE TABLE `peripherals` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `mpu_id` integer, `derived_from_id` integer, `name` varchar(255) NOT NULL UNIQUE, `base_address` varchar(255), `description` varchar(255), `block_offset` varchar(255), `block_size` varchar(255), `group_name` varchar(255));
+CREATE TABLE `registers` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `peripheral_id` integer, `name` varchar(255) NOT NULL, `address_offset` varchar(255), `reset_value` varchar(255), `description` varchar(255), `size` varchar(255), `access` varchar(255));
+CREATE TABLE `fields` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `register_id` integer, `name` varchar(255) NOT NULL, `num_bits` integer, `bit_offset` integer, `description` varchar(255), `access` varchar(255));
+CREATE TABLE `enums` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `field_id` integer, `name` varchar(255) NOT NULL, `value` integer, `description` varchar(255));
+CREATE TABLE `interrupts` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `peripheral_id` integer, `name` varchar(255) NOT NULL, `value` integer, `description` varchar(255));
+
+The enums and interrupts tables, the block_ and group_name peripheral columns and the size and access columns are only in databases created by newer versions of convert, so their use is optional
 */
 
 type BasicInfo struct {
@@ -36,20 +41,49 @@ type Peripheral struct {
 	BasicInfo
 	derived_from sql.Null[int]
 	base_address string
+	block_offset sql.Null[string]
+	block_size sql.Null[string]
+	group_name sql.Null[string]
 	registers *[]Register
 }
 
+// the columns selected for a peripheral, the newer columns are NULL in older databases
+var periph_columns string = "id, derived_from_id, name, base_address, description, NULL, NULL, NULL"
+
+// where to scan the periph_columns into
+func (p *Peripheral) scan_targets() []any {
+	return []any{&p.id, &p.derived_from, &p.name, &p.base_address, &p.description, &p.block_offset, &p.block_size, &p.group_name}
+}
+
 type Register struct {
 	BasicInfo
 	address_offset string
 	reset_value sql.Null[string]
+	size sql.Null[string]
+	access sql.Null[string]
 	fields *[]Field
 }
 
+// the columns selected for a register and field, size and access are NULL in older databases
+var reg_columns string = "id, name, address_offset, reset_value, description, NULL, NULL"
+var field_columns string = "id, name, num_bits, bit_offset, description, NULL"
+
 type Field struct {
 	BasicInfo
 	num_bits int
 	bit_offset int
+	access sql.Null[string]
+	enums *[]Enum
+}
+
+type Enum struct {
+	BasicInfo
+	value uint64
+}
+
+type Interrupt struct {
+	BasicInfo
+	value int
 }
 
 // print helpers for the structs
@@ -87,6 +121,9 @@ var cwd string
 var database string
 var verbose bool
 var mpu_id int
+var has_enums bool
+var has_interrupts bool
+var has_groups bool
 
 func FindUpwards(filename string) (string, error) {
 	if cwd == "" {
@@ -121,8 +158,8 @@ func SetDatabase(fn string) {
 	database = fn
 }
 
-func SetVerbose() {
-	verbose = true
+func SetVerbose(v bool) {
+	verbose = v
 }
 
 func OpenDatabase() (error) {
@@ -165,6 +202,17 @@ func OpenDatabase() (error) {
 	// just use the first one
 	mpu_id = mpus[0].id
 
+	// older databases do not have the enumerated values, interrupts, address blocks or groups
+	has_enums = table_exists("enums")
+	has_interrupts = table_exists("interrupts")
+	group_column := optional_column("peripherals", "group_name")
+	has_groups = group_column != "NULL"
+	periph_columns = "id, derived_from_id, name, base_address, description, " +
+		optional_column("peripherals", "block_offset") + ", " + optional_column("peripherals", "block_size") + ", " + group_column
+	reg_columns = "id, name, address_offset, reset_value, description, " +
+		optional_column("registers", "size") + ", " + optional_column("registers", "access")
+	field_columns = "id, name, num_bits, bit_offset, description, " + optional_column("fields", "access")
+
 	return nil
 }
 
@@ -195,6 +243,22 @@ func IntPow(base, exp int) int {
     return result
 }
 
+// parse a number as found in the SVD, which may be hex (0x), binary (0b or #) or decimal
+func parse_number(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	ls := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(ls, "0x"):
+		return strconv.ParseUint(s[2:], 16, 64)
+	case strings.HasPrefix(ls, "0b"):
+		return strconv.ParseUint(s[2:], 2, 64)
+	case strings.HasPrefix(s, "#"):
+		return strconv.ParseUint(s[1:], 2, 64)
+	default:
+		return strconv.ParseUint(s, 10, 64)
+	}
+}
+
 // collect all the registers and their fields for the named peripheral
 func collect_registers(periph string) (Peripheral, error) {
 	p, err := fetch_peripheral_by_name(periph)
@@ -202,6 +266,18 @@ func collect_registers(periph string) (Peripheral, error) {
 		return p, fmt.Errorf("Peripheral %v not found: %w", periph, err)
 	}
 
+	regs, err := fetch_registers_with_fields(p)
+	if err != nil {
+		return p, err
+	}
+
+	p.registers = &regs
+
+	return p, nil
+}
+
+// the registers of the peripheral, or the one it is derived from, with their fields and enums
+func fetch_registers_with_fields(p Peripheral) ([]Register, error) {
 	id := p.id
 
 	if p.derived_from.Valid {
@@ -210,31 +286,42 @@ func collect_registers(periph string) (Peripheral, error) {
 
 	regs, err := fetch_registers(id)
 	if err != nil {
-		return p, err
+		return nil, err
 	}
 	for i, r := range regs {
 		fields, err := fetch_fields(r.id)
 		if err != nil {
-			return p, err
+			return nil, err
+		}
+		if has_enums {
+			for j, f := range fields {
+				enums, err := fetch_enums(f.id)
+				if err != nil {
+					return nil, err
+				}
+				fields[j].enums = &enums
+			}
 		}
 		regs[i].fields = &fields
 	}
 
-	p.registers = &regs
-
-	return p, nil
+	return regs, nil
 }
 
 func Dump() (error) {
-	fmt.Println("MPU: ", getMPU())
-	fmt.Println("Database Dump:")
-
 	periphs, err := fetch_peripherals()
 
 	if err != nil {
 		return fmt.Errorf("failed to fetch peripherals - %w", err)
 	}
 
+	if !text_output() {
+		return dump_structured(periphs)
+	}
+
+	fmt.Println("MPU: ", getMPU())
+	fmt.Println("Database Dump:")
+
 	for _, p := range periphs {
 		if p.derived_from.Valid {
 			fmt.Print(p)
@@ -260,14 +347,59 @@ func Dump() (error) {
 	return nil
 }
 
-func List() (error) {
-	fmt.Println("Available Peripherals for MPU: ", getMPU())
+// derived peripherals just have the name of the one they are derived from, not the registers
+func dump_structured(periphs []Peripheral) error {
+	d, err := device_info()
+	if err != nil {
+		return err
+	}
 
+	for _, p := range periphs {
+		var regs []Register
+		if !p.derived_from.Valid {
+			pr, err := collect_registers(p.name)
+			if err != nil {
+				return err
+			}
+			regs = *pr.registers
+		}
+		pi, err := peripheral_info_with(p, regs)
+		if err != nil {
+			return err
+		}
+		d.Peripherals = append(d.Peripherals, pi)
+	}
+
+	return write_device(d)
+}
+
+func List() (error) {
 	periphs, err := fetch_peripherals()
 	if err != nil {
 		return fmt.Errorf("failed to fetch peripherals - %w", err)
 	}
 
+	return list_peripherals(periphs, "Available Peripherals for MPU: ")
+}
+
+func list_peripherals(periphs []Peripheral, heading string) (error) {
+	if !text_output() {
+		d, err := device_info()
+		if err != nil {
+			return err
+		}
+		for _, p := range periphs {
+			pi, err := peripheral_info(p)
+			if err != nil {
+				return err
+			}
+			d.Peripherals = append(d.Peripherals, pi)
+		}
+		return write_device(d)
+	}
+
+	fmt.Println(heading, getMPU())
+
 	for _, p := range periphs {
 		if verbose && p.description.Valid {
 			fmt.Println(p.name, " - ", p.description.V)
@@ -280,8 +412,6 @@ func List() (error) {
 }
 
 func Registers(periph string) (error) {
-	fmt.Println("Registers for Peripheral: ", periph, " for MPU: ", getMPU())
-
 	p, err := fetch_peripheral_by_name(periph)
 	if err != nil {
 		return fmt.Errorf("No peripheral with name like: %v - %w", periph, err)
@@ -297,6 +427,12 @@ func Registers(periph string) (error) {
 	if err != nil {
 		return err
 	}
+
+	if !text_output() {
+		return write_peripheral(p, regs)
+	}
+
+	fmt.Println("Registers for Peripheral: ", periph, " for MPU: ", getMPU())
 	for _, r := range regs {
 		fmt.Print(r.name)
 		if verbose && r.description.Valid {
@@ -335,7 +471,7 @@ func fetch_mpus() ([]MPU, error) {
 
 func fetch_peripherals() ([]Peripheral, error) {
 	var periphs []Peripheral
-	periph_rows, err := DB.Query("select id, derived_from_id, name, base_address, description from peripherals WHERE mpu_id = ? ORDER BY name", mpu_id)
+	periph_rows, err := DB.Query("SELECT " + periph_columns + " from peripherals WHERE mpu_id = ? ORDER BY name", mpu_id)
 	if err != nil {
 		return periphs, err
 	}
@@ -343,7 +479,7 @@ func fetch_peripherals() ([]Peripheral, error) {
 
 	for periph_rows.Next() {
 		var p Peripheral
-		err = periph_rows.Scan(&p.id, &p.derived_from, &p.name, &p.base_address, &p.description)
+		err = periph_rows.Scan(p.scan_targets()...)
 		if err != nil {
 			return periphs, err
 		}
@@ -359,7 +495,7 @@ func fetch_peripherals() ([]Peripheral, error) {
 
 func fetch_peripherals_like(s string) ([]Peripheral, error) {
 	var periphs []Peripheral
-	periph_rows, err := DB.Query("select id, derived_from_id, name, base_address, description from peripherals WHERE mpu_id = ? AND lower(name) LIKE lower(?) ORDER BY name", mpu_id, s)
+	periph_rows, err := DB.Query("SELECT " + periph_columns + " from peripherals WHERE mpu_id = ? AND lower(name) LIKE lower(?) ORDER BY name", mpu_id, s)
 	if err != nil {
 		return periphs, err
 	}
@@ -367,7 +503,7 @@ func fetch_peripherals_like(s string) ([]Peripheral, error) {
 
 	for periph_rows.Next() {
 		var p Peripheral
-		err = periph_rows.Scan(&p.id, &p.derived_from, &p.name, &p.base_address, &p.description)
+		err = periph_rows.Scan(p.scan_targets()...)
 		if err != nil {
 			return periphs, err
 		}
@@ -381,11 +517,55 @@ func fetch_peripherals_like(s string) ([]Peripheral, error) {
 	return periphs, nil
 }
 
+// the peripherals in the group, sorted by name
+func fetch_peripherals_in_group(group string) ([]Peripheral, error) {
+	if !has_groups {
+		return nil, errors.New("The database has no peripheral groups, convert the SVD again to add them")
+	}
+
+	var periphs []Peripheral
+	periph_rows, err := DB.Query("SELECT " + periph_columns + " from peripherals WHERE mpu_id = ? AND lower(group_name) = lower(?) ORDER BY name", mpu_id, group)
+	if err != nil {
+		return periphs, err
+	}
+	defer periph_rows.Close()
+
+	for periph_rows.Next() {
+		var p Peripheral
+		err = periph_rows.Scan(p.scan_targets()...)
+		if err != nil {
+			return periphs, err
+		}
+		periphs= append(periphs, p)
+	}
+
+	if err := periph_rows.Err(); err != nil {
+		return periphs, err
+	}
+
+	if len(periphs) == 0 {
+		return nil, fmt.Errorf("No peripherals in group %v", group)
+	}
+
+	return periphs, nil
+}
+
 func fetch_peripheral_by_name(periph string) (Peripheral, error) {
 	var p Peripheral
 
-    if err := DB.QueryRow("SELECT id, derived_from_id, name, base_address, description from peripherals WHERE mpu_id = ? AND lower(name) LIKE lower(?)", mpu_id, periph).
-    	Scan(&p.id, &p.derived_from, &p.name, &p.base_address, &p.description); err != nil {
+    if err := DB.QueryRow("SELECT " + periph_columns + " from peripherals WHERE mpu_id = ? AND lower(name) LIKE lower(?)", mpu_id, periph).
+    	Scan(p.scan_targets()...); err != nil {
+        	return p, err
+    }
+    return p, nil;
+}
+
+// the peripheral with exactly this name ignoring case, unlike fetch_peripheral_by_name % and _ are not wildcards
+func fetch_peripheral_by_exact_name(periph string) (Peripheral, error) {
+	var p Peripheral
+
+    if err := DB.QueryRow("SELECT " + periph_columns + " from peripherals WHERE mpu_id = ? AND lower(name) = lower(?)", mpu_id, periph).
+    	Scan(p.scan_targets()...); err != nil {
         	return p, err
     }
     return p, nil;
@@ -394,15 +574,15 @@ func fetch_peripheral_by_name(periph string) (Peripheral, error) {
 func fetch_peripheral(id int) (Peripheral, error) {
 	var p Peripheral
 
-    if err := DB.QueryRow("SELECT id, derived_from_id, name, base_address, description from peripherals WHERE mpu_id = ? AND id = ?", mpu_id, id).
-    	Scan(&p.id, &p.derived_from, &p.name, &p.base_address, &p.description); err != nil {
+    if err := DB.QueryRow("SELECT " + periph_columns + " from peripherals WHERE mpu_id = ? AND id = ?", mpu_id, id).
+    	Scan(p.scan_targets()...); err != nil {
         	return p, err
     }
     return p, nil;
 }
 
 func fetch_registers(p_id int) ([]Register, error) {
-	register_rows, err := DB.Query("select id, name, address_offset, reset_value, description from registers WHERE peripheral_id = ? ORDER BY name", p_id)
+	register_rows, err := DB.Query("select " + reg_columns + " from registers WHERE peripheral_id = ? ORDER BY name", p_id)
 
 	if err != nil {
 		return nil, fmt.Errorf("failure in fetch_registers query for id %v: %w", p_id, err)
@@ -412,7 +592,7 @@ func fetch_registers(p_id int) ([]Register, error) {
 	var registers []Register
 	for register_rows.Next() {
 		var reg Register
-		err = register_rows.Scan(&reg.id, &reg.name, &reg.address_offset, &reg.reset_value, &reg.description)
+		err = register_rows.Scan(&reg.id, &reg.name, &reg.address_offset, &reg.reset_value, &reg.description, &reg.size, &reg.access)
 		if err != nil {
 			return nil, fmt.Errorf("failure in fetch_registers scan for id %v: %w", p_id, err)
 		}
@@ -427,7 +607,7 @@ func fetch_registers(p_id int) ([]Register, error) {
 }
 
 func fetch_fields(r_id int) ([]Field, error) {
-	field_rows, err := DB.Query("select name, num_bits, bit_offset, description from fields WHERE register_id = ? ORDER BY bit_offset", r_id)
+	field_rows, err := DB.Query("select " + field_columns + " from fields WHERE register_id = ? ORDER BY bit_offset", r_id)
 
 	if err != nil {
 		return nil, fmt.Errorf("failure in fetch_fields query for id %v: %w", r_id, err)
@@ -436,7 +616,7 @@ func fetch_fields(r_id int) ([]Field, error) {
 	var fields []Field
 	for field_rows.Next() {
 		var f Field
-		err = field_rows.Scan(&f.name, &f.num_bits, &f.bit_offset, &f.description)
+		err = field_rows.Scan(&f.id, &f.name, &f.num_bits, &f.bit_offset, &f.description, &f.access)
 		if err != nil {
 			return nil, fmt.Errorf("failure in fetch_fields scan for id %v: %w", r_id, err)
 		}
@@ -449,3 +629,112 @@ func fetch_fields(r_id int) ([]Field, error) {
 
 	return fields, nil
 }
+
+func fetch_enums(f_id int) ([]Enum, error) {
+	enum_rows, err := DB.Query("select id, name, value, description from enums WHERE field_id = ? ORDER BY value", f_id)
+
+	if err != nil {
+		return nil, fmt.Errorf("failure in fetch_enums query for id %v: %w", f_id, err)
+	}
+	defer enum_rows.Close()
+	var enums []Enum
+	for enum_rows.Next() {
+		var e Enum
+		err = enum_rows.Scan(&e.id, &e.name, &e.value, &e.description)
+		if err != nil {
+			return nil, fmt.Errorf("failure in fetch_enums scan for id %v: %w", f_id, err)
+		}
+		enums= append(enums, e)
+	}
+
+	if err := enum_rows.Err(); err != nil {
+		return nil, fmt.Errorf("failure in fetch_enums rows for id %v: %w", f_id, err)
+	}
+
+	return enums, nil
+}
+
+func fetch_interrupts(p_id int) ([]Interrupt, error) {
+	irq_rows, err := DB.Query("select id, name, value, description from interrupts WHERE peripheral_id = ? ORDER BY value", p_id)
+
+	if err != nil {
+		return nil, fmt.Errorf("failure in fetch_interrupts query for id %v: %w", p_id, err)
+	}
+	defer irq_rows.Close()
+	var irqs []Interrupt
+	for irq_rows.Next() {
+		var irq Interrupt
+		err = irq_rows.Scan(&irq.id, &irq.name, &irq.value, &irq.description)
+		if err != nil {
+			return nil, fmt.Errorf("failure in fetch_interrupts scan for id %v: %w", p_id, err)
+		}
+		irqs = append(irqs, irq)
+	}
+
+	if err := irq_rows.Err(); err != nil {
+		return nil, fmt.Errorf("failure in fetch_interrupts rows for id %v: %w", p_id, err)
+	}
+
+	return irqs, nil
+}
+
+// a peripheral, register or field whose name matched a search, register and field are "" for a peripheral
+type name_match struct {
+	periph string
+	base_address string
+	reg string
+	address_offset string
+	field string
+}
+
+// all the peripherals, registers and fields with s in their name, derived peripherals match the registers they share
+func fetch_name_matches(s string) ([]name_match, error) {
+	queries := []string{
+		"SELECT name, base_address, '', '', '' FROM peripherals WHERE mpu_id = ? AND instr(lower(name), lower(?)) > 0",
+		"SELECT p.name, p.base_address, r.name, r.address_offset, '' FROM registers r " +
+			"JOIN peripherals p ON p.id = r.peripheral_id OR p.derived_from_id = r.peripheral_id " +
+			"WHERE p.mpu_id = ? AND instr(lower(r.name), lower(?)) > 0",
+		"SELECT p.name, p.base_address, r.name, r.address_offset, f.name FROM fields f JOIN registers r ON r.id = f.register_id " +
+			"JOIN peripherals p ON p.id = r.peripheral_id OR p.derived_from_id = r.peripheral_id " +
+			"WHERE p.mpu_id = ? AND instr(lower(f.name), lower(?)) > 0",
+	}
+
+	var matches []name_match
+	for _, q := range queries {
+		rows, err := DB.Query(q + " ORDER BY 1, 3, 5", mpu_id, s)
+		if err != nil {
+			return nil, fmt.Errorf("failure in fetch_name_matches query for %v: %w", s, err)
+		}
+		for rows.Next() {
+			var m name_match
+			if err := rows.Scan(&m.periph, &m.base_address, &m.reg, &m.address_offset, &m.field); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failure in fetch_name_matches scan for %v: %w", s, err)
+			}
+			matches = append(matches, m)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failure in fetch_name_matches rows for %v: %w", s, err)
+		}
+	}
+
+	return matches, nil
+}
+
+// returns the column name if it is in the table or NULL if it is not
+func optional_column(table string, column string) string {
+	var n int
+	if err := DB.QueryRow("SELECT count(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n); err != nil || n == 0 {
+		return "NULL"
+	}
+	return column
+}
+
+func table_exists(name string) bool {
+	var n int
+	if err := DB.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n); err != nil {
+		return false
+	}
+	return n > 0
+}
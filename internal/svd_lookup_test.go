@@ -0,0 +1,58 @@
+package svd_lookup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wolfmanjm/svd_lookup/svd2db"
+)
+
+// all the tests run against a database converted from the LPC176x5x test svd
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "svd_lookup")
+	if err != nil {
+		panic(err)
+	}
+
+	fn := filepath.Join(dir, "test3.db")
+	if err := svd2db.Convert("../svd2db/testdata/test3.svd", fn); err != nil {
+		panic(err)
+	}
+
+	SetDatabase(fn)
+	if err := OpenDatabase(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	CloseDatabase()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := map[string]uint64{"0x1F": 31, "0X10": 16, "0b101": 5, "#110": 6, "42": 42, " 7 ": 7}
+	for s, want := range tests {
+		v, err := parse_number(s)
+		if v != want || err != nil {
+			t.Errorf(`parse_number("%v") = %v, %v, want %v, nil`, s, v, err, want)
+		}
+	}
+
+	if _, err := parse_number("0xZZ"); err == nil {
+		t.Errorf(`parse_number("0xZZ") = nil error, want error`)
+	}
+}
+
+func TestCollectRegisters(t *testing.T) {
+	// TIMER1 is derived from TIMER0 so should get its registers
+	pr, err := collect_registers("TIMER1")
+	if err != nil {
+		t.Fatalf(`collect_registers("TIMER1") = %v, want nil`, err)
+	}
+	if pr.registers == nil || len(*pr.registers) != 11 {
+		t.Errorf(`collect_registers("TIMER1") has %v registers, want 11`, pr.registers)
+	}
+}
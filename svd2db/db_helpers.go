@@ -14,11 +14,15 @@ import (
 
 	CREATE TABLE sqlite_sequence(name,seq);
 
-	CREATE TABLE `peripherals` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `mpu_id` integer, `derived_from_id` integer, `name` varchar(255) NOT NULL UNIQUE, `base_address` varchar(255), `description` varchar(255));
+	CREATE TABLE `peripherals` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `mpu_id` integer, `derived_from_id` integer, `name` varchar(255) NOT NULL UNIQUE, `base_address` varchar(255), `description` varchar(255), `block_offset` varchar(255), `block_size` varchar(255), `group_name` varchar(255));
 
-	CREATE TABLE `registers` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `peripheral_id` integer, `name` varchar(255) NOT NULL, `address_offset` varchar(255), `reset_value` varchar(255), `description` varchar(255));
+	CREATE TABLE `registers` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `peripheral_id` integer, `name` varchar(255) NOT NULL, `address_offset` varchar(255), `reset_value` varchar(255), `description` varchar(255), `size` varchar(255), `access` varchar(255));
 
-	CREATE TABLE `fields` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `register_id` integer, `name` varchar(255) NOT NULL, `num_bits` integer, `bit_offset` integer, `description` varchar(255));
+	CREATE TABLE `fields` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `register_id` integer, `name` varchar(255) NOT NULL, `num_bits` integer, `bit_offset` integer, `description` varchar(255), `access` varchar(255));
+
+	CREATE TABLE `enums` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `field_id` integer, `name` varchar(255) NOT NULL, `value` integer, `description` varchar(255));
+
+	CREATE TABLE `interrupts` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `peripheral_id` integer, `name` varchar(255) NOT NULL, `value` integer, `description` varchar(255));
 */
 
 func db_createdb(filename string) (*sql.DB, error) {
@@ -36,9 +40,11 @@ func db_createdb(filename string) (*sql.DB, error) {
 
 	sqlStmt := `
 CREATE TABLE mpus (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, name text NOT NULL UNIQUE, description text);
-CREATE TABLE peripherals (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, mpu_id integer NOT NULL, derived_from_id integer, name text NOT NULL UNIQUE, base_address text NOT NULL, description text);
-CREATE TABLE registers (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, peripheral_id integer NOT NULL, name text NOT NULL, address_offset text NOT NULL, reset_value text, description text);
-CREATE TABLE fields (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, register_id integer NOT NULL, name text NOT NULL, num_bits integer NOT NULL, bit_offset integer NOT NULL, description text);
+CREATE TABLE peripherals (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, mpu_id integer NOT NULL, derived_from_id integer, name text NOT NULL UNIQUE, base_address text NOT NULL, description text, block_offset text, block_size text, group_name text);
+CREATE TABLE registers (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, peripheral_id integer NOT NULL, name text NOT NULL, address_offset text NOT NULL, reset_value text, description text, size text, access text);
+CREATE TABLE fields (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, register_id integer NOT NULL, name text NOT NULL, num_bits integer NOT NULL, bit_offset integer NOT NULL, description text, access text);
+CREATE TABLE enums (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, field_id integer NOT NULL, name text NOT NULL, value integer NOT NULL, description text);
+CREATE TABLE interrupts (id integer NOT NULL PRIMARY KEY AUTOINCREMENT, peripheral_id integer NOT NULL, name text NOT NULL, value integer NOT NULL, description text);
 	`
 	_, err = db.Exec(sqlStmt)
 	if err != nil {
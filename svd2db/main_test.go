@@ -0,0 +1,15 @@
+package svd2db
+
+import (
+	"os"
+	"testing"
+)
+
+// TestConvert and the db_createdb tests write their databases into testdata, they are removed afterwards
+// so the tree is left clean and the next run can create them again
+func TestMain(m *testing.M) {
+	code := m.Run()
+	os.Remove("testdata/test.db")
+	os.Remove("testdata/testdbfile.db")
+	os.Exit(code)
+}
@@ -15,6 +15,8 @@ type Device struct {
 	XMLName     xml.Name     `xml:"device"`
 	Name        string       `xml:"name"`
 	Description string       `xml:"description"`
+	Size        string       `xml:"size"`
+	Access      string       `xml:"access"`
 	Peripherals []Peripheral `xml:"peripherals>peripheral"`
 }
 
@@ -23,9 +25,18 @@ type Peripheral struct {
 	Description  string       `xml:"description"`
 	BaseAddress  string       `xml:"baseAddress"`
 	GroupName    string       `xml:"groupName"`
+	Size         string       `xml:"size"`
+	Access       string       `xml:"access"`
 	Registers    []Register   `xml:"registers>register"`
 	DerivedFrom  string       `xml:"derivedFrom,attr"`
-	AddressBlock AddressBlock `xml:"addressBlock"`
+	AddressBlocks []AddressBlock `xml:"addressBlock"`
+	Interrupts   []Interrupt  `xml:"interrupt"`
+}
+
+type Interrupt struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Value       string `xml:"value"`
 }
 
 type AddressBlock struct {
@@ -53,10 +64,44 @@ type Field struct {
 	Access      string `xml:"access"`
 	LSB         string `xml:"lsb"`
 	MSB         string `xml:"msb"`
+	EnumeratedValues []EnumeratedValues `xml:"enumeratedValues"`
+}
+
+type EnumeratedValues struct {
+	Name   string            `xml:"name"`
+	Usage  string            `xml:"usage"`
+	Values []EnumeratedValue `xml:"enumeratedValue"`
+}
+
+type EnumeratedValue struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Value       string `xml:"value"`
+	IsDefault   string `xml:"isDefault"`
+}
+
+// the default register size and access, set for the device and overridden by peripherals and registers
+type registerProperties struct {
+	Size   string
+	Access string
+}
+
+func (rp registerProperties) inherit(size string, access string) registerProperties {
+	if size != "" {
+		rp.Size = size
+	}
+	if access != "" {
+		rp.Access = access
+	}
+	return rp
 }
 
 // keeps a list of peripherals to id mapping, needed for derived_from peripherals
 var periph_ids map[string]int
+// the address block of each peripheral, derived peripherals inherit it if they do not have their own
+var periph_blocks map[string]AddressBlock
+// the group of each peripheral, derived peripherals are in the same group unless they have their own
+var periph_groups map[string]string
 // var deferred_derived_from map[string]string
 
 func Convert(filename string, ofile string) error {
@@ -95,11 +140,14 @@ func Convert(filename string, ofile string) error {
 	}
 
 	periph_ids = make(map[string]int)
+	periph_blocks = make(map[string]AddressBlock)
+	periph_groups = make(map[string]string)
 	// deferred_derived_from = make(map[string]string)
 
 	// insert peripherals and their registers
+	defaults := registerProperties{}.inherit(device.Size, device.Access)
 	for _, peripheral := range device.Peripherals {
-		if err := insertPeripheral(db, mpu_id, peripheral); err != nil {
+		if err := insertPeripheral(db, mpu_id, peripheral, defaults); err != nil {
 			return fmt.Errorf("in convert inserting 'peripherals' to database: %w\n", err)
 		}
 	}
@@ -109,7 +157,7 @@ func Convert(filename string, ofile string) error {
 	return nil
 }
 
-func insertPeripheral(db *sql.DB, mpu_id int, p Peripheral) error {
+func insertPeripheral(db *sql.DB, mpu_id int, p Peripheral, defaults registerProperties) error {
 	fmt.Println("Processing Peripheral: " + p.Name)
 
 	m := map[string]any{"name": p.Name, "mpu_id": mpu_id, "base_address": p.BaseAddress}
@@ -118,6 +166,28 @@ func insertPeripheral(db *sql.DB, mpu_id int, p Peripheral) error {
 		m["description"] = p.Description
 	}
 
+	block, err := addressBlockSpan(p.AddressBlocks)
+	if err != nil {
+		return fmt.Errorf("in insertPeripheral peripheral %v: %w\n", p.Name, err)
+	}
+	if block.Size == "" && p.DerivedFrom != "" {
+		block = periph_blocks[p.DerivedFrom]
+	}
+	if block.Size != "" {
+		m["block_offset"] = block.Offset
+		m["block_size"] = block.Size
+	}
+	periph_blocks[p.Name] = block
+
+	group := strings.TrimSpace(p.GroupName)
+	if group == "" && p.DerivedFrom != "" {
+		group = periph_groups[p.DerivedFrom]
+	}
+	if group != "" {
+		m["group_name"] = group
+	}
+	periph_groups[p.Name] = group
+
 	derived_from_flg := false
 	if p.DerivedFrom != "" {
 		elem, ok := periph_ids[p.DerivedFrom]
@@ -140,10 +210,17 @@ func insertPeripheral(db *sql.DB, mpu_id int, p Peripheral) error {
 	// add to list of peripherals and the ids
 	periph_ids[p.Name] = peripheral_id
 
+	// derived peripherals have their own interrupts
+	for _, irq := range p.Interrupts {
+		if err := insertInterrupt(db, peripheral_id, irq); err != nil {
+			return fmt.Errorf("in insertPeripheral inserting interrupts to database: %w\n",  err)
+		}
+	}
+
 	if !derived_from_flg && len(p.Registers) > 0 {
 		// Insert registers
 		for _, register := range p.Registers {
-			if err := insertRegister(db, peripheral_id, register); err != nil {
+			if err := insertRegister(db, peripheral_id, register, defaults.inherit(p.Size, p.Access)); err != nil {
 				return fmt.Errorf("in insertPeripheral inserting registers to database: %w\n",  err)
 			}
 		}
@@ -152,7 +229,53 @@ func insertPeripheral(db *sql.DB, mpu_id int, p Peripheral) error {
 	return nil
 }
 
-func insertRegister(db *sql.DB, peripheral_id int, r Register) error {
+func insertInterrupt(db *sql.DB, peripheral_id int, irq Interrupt) error {
+	v, err := parseNumber(irq.Value)
+	if err != nil {
+		return fmt.Errorf("in insertInterrupt converting value %v for %v: %w\n", irq.Value, irq.Name, err)
+	}
+
+	m := map[string]any{"name": irq.Name, "peripheral_id": peripheral_id, "value": v}
+
+	if irq.Description != "" {
+		m["description"] = irq.Description
+	}
+
+	_, err = db_insert(db, "interrupts", m)
+	if err != nil {
+		return fmt.Errorf("in insertInterrupt inserting %v to database: %w\n", irq.Name, err)
+	}
+
+	return nil
+}
+
+// a peripheral may have several address blocks, this returns the one block that covers them all
+func addressBlockSpan(blocks []AddressBlock) (AddressBlock, error) {
+	var lo, hi int64
+	for i, b := range blocks {
+		off, err := parseNumber(b.Offset)
+		if err != nil {
+			return AddressBlock{}, fmt.Errorf("converting address block offset %v: %w", b.Offset, err)
+		}
+		size, err := parseNumber(b.Size)
+		if err != nil {
+			return AddressBlock{}, fmt.Errorf("converting address block size %v: %w", b.Size, err)
+		}
+		if i == 0 || off < lo {
+			lo = off
+		}
+		if i == 0 || off+size > hi {
+			hi = off + size
+		}
+	}
+
+	if len(blocks) == 0 {
+		return AddressBlock{}, nil
+	}
+	return AddressBlock{Offset: fmt.Sprintf("0x%X", lo), Size: fmt.Sprintf("0x%X", hi-lo)}, nil
+}
+
+func insertRegister(db *sql.DB, peripheral_id int, r Register, defaults registerProperties) error {
 	// fmt.Println("Processing Register: " + r.Name)
 	m := map[string]any{"name": r.Name, "peripheral_id": peripheral_id, "address_offset": r.Offset}
 
@@ -164,6 +287,14 @@ func insertRegister(db *sql.DB, peripheral_id int, r Register) error {
 		m["reset_value"] = r.ResetValue
 	}
 
+	props := defaults.inherit(r.Size, r.Access)
+	if props.Size != "" {
+		m["size"] = props.Size
+	}
+	if props.Access != "" {
+		m["access"] = props.Access
+	}
+
 	// enter into the database
 	register_id, err := db_insert(db, "registers", m)
 	if err != nil {
@@ -173,7 +304,7 @@ func insertRegister(db *sql.DB, peripheral_id int, r Register) error {
 	// Insert fields
 	if len(r.Fields) > 0 {
 		for _, field := range r.Fields {
-			if err := insertField(db, register_id, field); err != nil {
+			if err := insertField(db, register_id, field, props.Access); err != nil {
 				return fmt.Errorf("in insertRegister inserting fields to database: %w\n",  err)
 			}
 		}
@@ -182,7 +313,7 @@ func insertRegister(db *sql.DB, peripheral_id int, r Register) error {
 	return nil
 }
 
-func insertField(db *sql.DB, register_id int, f Field) error {
+func insertField(db *sql.DB, register_id int, f Field, access string) error {
 	// fmt.Println("Processing Field: " + f.Name)
 	m := map[string]any{"name": f.Name, "register_id": register_id}
 
@@ -190,6 +321,14 @@ func insertField(db *sql.DB, register_id int, f Field) error {
 		m["description"] = f.Description
 	}
 
+	// fields have the access of the register unless they have their own
+	if f.Access != "" {
+		access = f.Access
+	}
+	if access != "" {
+		m["access"] = access
+	}
+
 	// Handle different bit position formats and convert to num_bits and bit_offset
 	var bit_offset, num_bits int
 	var err error
@@ -228,10 +367,63 @@ func insertField(db *sql.DB, register_id int, f Field) error {
 	m["bit_offset"] =  bit_offset
 
 	// enter into the database
-	_, err = db_insert(db, "fields", m)
+	field_id, err := db_insert(db, "fields", m)
 	if err != nil {
 		return fmt.Errorf("in insertField inserting %v to database: %w\n", f.Name, err)
 	}
 
+	// Insert enumerated values
+	for _, ev := range f.EnumeratedValues {
+		for _, e := range ev.Values {
+			if err := insertEnum(db, field_id, e); err != nil {
+				return fmt.Errorf("in insertField inserting enums to database: %w\n",  err)
+			}
+		}
+	}
+
 	return nil
 }
+
+func insertEnum(db *sql.DB, field_id int, e EnumeratedValue) error {
+	// default values and don't care bits (eg #1xx) do not have a single value so are skipped
+	if e.IsDefault == "true" || e.Value == "" {
+		return nil
+	}
+	if strings.HasPrefix(e.Value, "#") && strings.ContainsAny(e.Value, "xX") {
+		return nil
+	}
+
+	v, err := parseNumber(e.Value)
+	if err != nil {
+		return fmt.Errorf("in insertEnum converting value %v for %v: %w\n", e.Value, e.Name, err)
+	}
+
+	m := map[string]any{"name": e.Name, "field_id": field_id, "value": v}
+
+	if e.Description != "" {
+		m["description"] = e.Description
+	}
+
+	_, err = db_insert(db, "enums", m)
+	if err != nil {
+		return fmt.Errorf("in insertEnum inserting %v to database: %w\n", e.Name, err)
+	}
+
+	return nil
+}
+
+// numbers in the SVD can be in hex (0x), binary (0b or #) or decimal
+func parseNumber(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	ls := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(ls, "0x"):
+		return strconv.ParseInt(s[2:], 16, 64)
+	case strings.HasPrefix(ls, "0b"):
+		return strconv.ParseInt(s[2:], 2, 64)
+	case strings.HasPrefix(s, "#"):
+		return strconv.ParseInt(s[1:], 2, 64)
+	default:
+		return strconv.ParseInt(s, 10, 64)
+	}
+}
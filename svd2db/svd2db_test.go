@@ -11,3 +11,13 @@ func TestConvert(t *testing.T) {
         t.Errorf(`Convert("%v") = %v, want nil`, fn, err)
     }
 }
+
+func TestParseNumber(t *testing.T) {
+	tests := map[string]int64{"0x1F": 31, "0b011": 3, "#10": 2, "12": 12}
+	for s, want := range tests {
+		v, err := parseNumber(s)
+		if v != want || err != nil {
+			t.Errorf(`parseNumber("%v") = %v, %v, want %v, nil`, s, v, err, want)
+		}
+	}
+}